@@ -0,0 +1,210 @@
+package corpus
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Bigrams is a bigram language model that backs off to the unigram probabilities of a Vocabulary.
+//
+// The probability of a word given the previous word is the linear interpolation
+//
+//	λ P(word | prev) + (1-λ) P(word)
+//
+// where P(word | prev) is the maximum likelihood estimate from the bigram counts. If prev was never seen as a context, the unigram probability is used as is.
+// The beginning of a sentence is represented by the empty word (the NULL entry of a Corpus).
+type Bigrams struct {
+	pairs    Vocab[[2]string]
+	contexts map[string]int // how many times each word has been seen as the left half of a pair
+
+	unigrams Vocabulary
+	lambda   float64
+}
+
+// maxLambda is the largest weight given to the bigram estimate, so that the unigram probability is never multiplied by 0 and unseen pairs stay possible.
+const maxLambda = 1 - 1e-6
+
+// NewBigrams creates a new bigram model that backs off to the given unigrams. lambda is the weight given to the bigram estimate, and should be in [0, 1].
+// It is clamped to that range, and a lambda of 1 is taken as just below 1.
+func NewBigrams(unigrams Vocabulary, lambda float64) *Bigrams {
+	switch {
+	case lambda < 0 || math.IsNaN(lambda):
+		lambda = 0
+	case lambda > maxLambda:
+		lambda = maxLambda
+	}
+	return &Bigrams{
+		pairs:    makeVocab[[2]string](0),
+		contexts: make(map[string]int),
+		unigrams: unigrams,
+		lambda:   lambda,
+	}
+}
+
+// Unigrams returns the vocabulary the model backs off to.
+func (b *Bigrams) Unigrams() Vocabulary { return b.unigrams }
+
+// Add records an occurrence of word following prev.
+func (b *Bigrams) Add(prev, word string) { b.AddN(prev, word, 1) }
+
+// AddN records n occurrences of word following prev.
+func (b *Bigrams) AddN(prev, word string, n int) {
+	b.pairs.AddN([2]string{prev, word}, n)
+	b.contexts[prev] += n
+}
+
+// AddSentence records all the bigrams of a sentence, including the one from the beginning of the sentence to the first word.
+func (b *Bigrams) AddSentence(words []string) {
+	prev := ""
+	for _, w := range words {
+		b.Add(prev, w)
+		prev = w
+	}
+}
+
+// Freq returns the number of times word was seen following prev.
+func (b *Bigrams) Freq(prev, word string) int { return b.pairs.WordFreq([2]string{prev, word}) }
+
+// Size returns the number of distinct bigrams.
+func (b *Bigrams) Size() int { return b.pairs.Size() }
+
+// Prob returns the interpolated probability of word following prev, and whether or not word is known to the unigrams.
+func (b *Bigrams) Prob(prev, word string) (float64, bool) {
+	p, ok := b.unigrams.WordProb(word)
+	if !ok {
+		return 0, false
+	}
+	return math.Exp(b.logProb(prev, word, math.Log(p))), true
+}
+
+// logProb interpolates the bigram estimate with the given unigram log probability, which may be the log probability of an unknown word.
+func (b *Bigrams) logProb(prev, word string, unigram float64) float64 {
+	ctx := b.contexts[prev]
+	if ctx == 0 {
+		return unigram
+	}
+	count := b.pairs.WordFreq([2]string{prev, word})
+	if count == 0 {
+		return math.Log(1-b.lambda) + unigram
+	}
+	ml := float64(count) / float64(ctx)
+	// in log space, so that tiny unigram probabilities don't underflow
+	return logAddExp(math.Log(b.lambda*ml), math.Log(1-b.lambda)+unigram)
+}
+
+// LoadTwoGram loads a 2_gram.txt file, which is a tab separated file which lists the frequency counts of pairs of words. Example:
+//
+//	of the	2766332391
+//	in the	1628795324
+//	to the	1139248999
+//
+// The beginning of a sentence may be written as <S>, as in Norvig's count_2w.txt.
+func (b *Bigrams) LoadTwoGram(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		splits := strings.Split(line, "\t")
+		if len(splits) != 2 {
+			return errors.Errorf("Expected a pair of words and a count. Got %q instead", line)
+		}
+		words := strings.Fields(splits[0])
+		if len(words) != 2 {
+			return errors.Errorf("Expected a pair of words. Got %q instead", splits[0])
+		}
+		if words[0] == "<S>" {
+			words[0] = ""
+		}
+
+		count, err := strconv.Atoi(splits[1])
+		if err != nil {
+			return err
+		}
+		b.AddN(words[0], words[1], count)
+	}
+	return scanner.Err()
+}
+
+// WithBigrams makes ViterbiSegment and ViterbiSegmentN score each word given the word before it, using the bigram model.
+// Bigrams are looked up by their lowercased words.
+func WithBigrams(b *Bigrams) SegmentOpt {
+	return func(s *segmenter) { s.bigrams = b }
+}
+
+// BigramSplit is like ViterbiSplit, but scores splits with a bigram model, backing off to the model's unigrams.
+func BigramSplit(input string, b *Bigrams) []string {
+	return ViterbiSegment(input, b.unigrams, WithBigrams(b)).Words
+}
+
+// segmentBigram is the Viterbi search over states made of a position and the length of the word that ends there.
+func (s *segmenter) segmentBigram(input string) Segmentation {
+	l := s.prepare(input)
+	n := l.n()
+
+	// scores[i][d] is the log probability of the best segmentation of the first i runes whose last word is d runes long.
+	// backs[i][d] is the length of the word before that last word.
+	scores := make([][]float64, n+1)
+	backs := make([][]int, n+1)
+	for i := range scores {
+		scores[i] = make([]float64, s.maxLen+1)
+		backs[i] = make([]int, s.maxLen+1)
+		for d := range scores[i] {
+			scores[i][d] = math.Inf(-1)
+		}
+	}
+	scores[0][0] = 0
+
+	for j := 0; j < n; j++ {
+		s.arcs(l, j, func(i int, lp float64) {
+			word := l.lowerWord(j, i)
+			d := i - j
+			for pd, prevScore := range scores[j] {
+				if math.IsInf(prevScore, -1) {
+					continue
+				}
+				prev := l.lowerWord(j-pd, j)
+				if score := prevScore + s.bigrams.logProb(prev, word, lp); score > scores[i][d] {
+					scores[i][d] = score
+					backs[i][d] = pd
+				}
+			}
+		})
+	}
+
+	best := math.Inf(-1)
+	var d int
+	for pd, score := range scores[n] {
+		if score > best {
+			best, d = score, pd
+		}
+	}
+	if n == 0 {
+		best = 0
+	}
+	if math.IsInf(best, -1) {
+		// no path is possible with the bigrams, so there is nothing to trace back
+		u := *s
+		u.bigrams = nil
+		return u.segment(input)
+	}
+
+	words := make([]string, 0)
+	for i := n; i > 0; {
+		words = append(words, l.word(i-d, i))
+		i, d = i-d, backs[i][d]
+	}
+
+	// reverse it
+	for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+		words[i], words[j] = words[j], words[i]
+	}
+
+	return Segmentation{Words: words, Score: best}
+}
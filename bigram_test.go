@@ -0,0 +1,108 @@
+package corpus
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBigrams(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"the", "the", "cat", "sat", "dog"}))
+	require.NoError(t, err)
+
+	b := NewBigrams(c, 0.5)
+	b.AddSentence([]string{"the", "cat", "sat"})
+	b.AddSentence([]string{"the", "dog"})
+	assert.Equal(4, b.Size())
+	assert.Equal(2, b.Freq("", "the"))
+	assert.Equal(1, b.Freq("the", "cat"))
+
+	p, ok := b.Prob("the", "cat")
+	assert.True(ok)
+	assert.True(floatEquals64(0.5*0.5+0.5*0.2, p))
+
+	// unseen pair with a seen context
+	p, ok = b.Prob("cat", "dog")
+	assert.True(ok)
+	assert.True(floatEquals64(0.5*0.2, p))
+
+	// unseen context backs off to the unigrams entirely
+	p, ok = b.Prob("dog", "cat")
+	assert.True(ok)
+	assert.True(floatEquals64(0.2, p))
+
+	_, ok = b.Prob("the", "mouse")
+	assert.False(ok)
+}
+
+func TestBigrams_LoadTwoGram(t *testing.T) {
+	c := New()
+	require.NoError(t, c.LoadOneGram(strings.NewReader(sample1Gram)))
+	b := NewBigrams(c, 0.9)
+	require.NoError(t, b.LoadTwoGram(strings.NewReader("of the\t2766332391\n<S> the\t258483382\n")))
+	assert.Equal(t, 2766332391, b.Freq("of", "the"))
+	assert.Equal(t, 258483382, b.Freq("", "the"))
+
+	assert.Error(t, b.LoadTwoGram(strings.NewReader("of\t12\n")))
+}
+
+func TestBigramSplit(t *testing.T) {
+	assert := assert.New(t)
+
+	// on unigrams alone, "expert sex change" wins
+	var words []string
+	for i := 0; i < 10; i++ {
+		words = append(words, "expert", "sex", "change")
+	}
+	words = append(words, "experts", "exchange", "stock")
+	c, err := Construct(WithWords(words))
+	require.NoError(t, err)
+	assert.Equal([]string{"expert", "sex", "change"}, ViterbiSplit("expertsexchange", c))
+
+	// but in context, "experts exchange" is what people write
+	b := NewBigrams(c, 0.9)
+	for i := 0; i < 10; i++ {
+		b.AddSentence([]string{"experts", "exchange"})
+		b.AddSentence([]string{"stock", "exchange"})
+	}
+	b.AddSentence([]string{"expert"})
+	assert.Equal([]string{"experts", "exchange"}, BigramSplit("expertsexchange", b))
+
+	seg := ViterbiSegment("expertsexchange", c, WithBigrams(b))
+	expected := math.Log(0.9*10.0/21.0+0.1*1.0/33.0) + math.Log(0.9*1.0+0.1*1.0/33.0)
+	assert.True(floatEquals64(expected, seg.Score), "expected %v, got %v", expected, seg.Score)
+
+	seg = ViterbiSegment("", c, WithBigrams(b))
+	assert.Empty(seg.Words)
+	assert.Equal(0.0, seg.Score)
+}
+
+func TestBigramSplit_Lambda(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"a", "b"}))
+	require.NoError(t, err)
+
+	// with all the weight on the bigrams, unseen pairs would be impossible
+	b := NewBigrams(c, 1)
+	b.AddSentence([]string{"a", "b"})
+	p, ok := b.Prob("a", "a")
+	assert.True(ok)
+	assert.True(p > 0)
+	assert.Equal([]string{"b", "a", "b"}, BigramSplit("bab", b))
+
+	b = NewBigrams(c, -1)
+	b.AddSentence([]string{"a", "b"})
+	p, _ = b.Prob("a", "b")
+	assert.True(floatEquals64(0.5, p))
+
+	// when no path is possible at all, the unigram segmentation is used
+	b = NewBigrams(c, 0)
+	b.lambda = 1
+	b.AddSentence([]string{"a", "b"})
+	seg := ViterbiSegment("bab", c, WithBigrams(b))
+	assert.Equal(ViterbiSegment("bab", c), seg)
+}
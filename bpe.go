@@ -0,0 +1,420 @@
+package corpus
+
+import (
+	"container/heap"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Merge is a pair of adjacent symbols that byte-pair encoding merges into one.
+type Merge struct {
+	Left, Right string
+}
+
+// maxBPECache is the number of encoded words a BPE keeps.
+const maxBPECache = 1 << 16
+
+// BPEOpt is an option for TrainBPE and NewBPE.
+type BPEOpt func(b *BPE)
+
+// WithEndOfWord sets the marker appended to the last symbol of every word, such as "</w>". The marker lets merges tell
+// the ends of words apart from their insides. The default is no marker.
+func WithEndOfWord(marker string) BPEOpt {
+	return func(b *BPE) { b.endOfWord = marker }
+}
+
+// WithSpecialTokens adds tokens that are never merged or split, such as "<pad>" or "<s>". When training, they are given the first IDs of the subword vocabulary.
+func WithSpecialTokens(tokens ...string) BPEOpt {
+	return func(b *BPE) { b.specials = append(b.specials, tokens...) }
+}
+
+// WithUnknownToken sets the token that symbols missing from the subword vocabulary are encoded as. The default is "-UNKNOWN-".
+// It is added to the special tokens. If it is empty, or isn't in the vocabulary, missing symbols are encoded as -1.
+func WithUnknownToken(token string) BPEOpt {
+	return func(b *BPE) { b.unknown = token }
+}
+
+// BPE is a byte-pair encoding subword model: a subword vocabulary, and an ordered list of merges that builds the subwords of the vocabulary up from single symbols.
+// A *BPE is safe for concurrent use.
+type BPE struct {
+	vocab  Vocabulary
+	merges []Merge
+	ranks  map[Merge]int
+
+	endOfWord string
+	specials  []string
+	unknown   string
+
+	// split turns a word into its initial symbols. It defaults to splitting into runes, with the end of word marker appended to the last one.
+	split func(word string) []string
+
+	mu    sync.RWMutex
+	cache map[string][]string
+}
+
+// NewBPE creates a BPE model from an existing subword vocabulary and merge list, in order of priority. This is useful for loading models trained elsewhere.
+func NewBPE(vocab Vocabulary, merges []Merge, opts ...BPEOpt) *BPE {
+	b := newBPE(opts...)
+	b.vocab = vocab
+	b.merges = merges
+	for i, m := range merges {
+		if _, ok := b.ranks[m]; !ok {
+			b.ranks[m] = i
+		}
+	}
+	return b
+}
+
+func newBPE(opts ...BPEOpt) *BPE {
+	b := &BPE{
+		ranks:   make(map[Merge]int),
+		unknown: "-UNKNOWN-",
+		cache:   make(map[string][]string),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.split == nil {
+		b.split = b.splitRunes
+	}
+
+	hasUnknown := b.unknown == ""
+	for _, s := range b.specials {
+		hasUnknown = hasUnknown || s == b.unknown
+	}
+	if !hasUnknown {
+		b.specials = append(b.specials, b.unknown)
+	}
+	return b
+}
+
+// TrainBPE learns byte-pair encoding merges from the words and frequencies of a vocabulary, such as a *Corpus, until the subword vocabulary
+// (special tokens, initial symbols and merged symbols) reaches vocabSize, or there is nothing left to merge.
+// The special words of the vocabulary are not part of the training data.
+//
+// The vocabulary of the returned model is a *Corpus. The frequency of each subword in it is the number of times it occurs when the training words are encoded.
+func TrainBPE(c Vocabulary, vocabSize int, opts ...BPEOpt) (*BPE, error) {
+	b := newBPE(opts...)
+
+	// the training words, split into symbols
+	var words [][]string
+	var freqs []int
+	for id := 0; id < c.Size(); id++ {
+		w, _ := c.Word(id)
+		freq := c.WordFreq(w)
+		if isSpecialIn(c, w) || freq < 1 {
+			continue
+		}
+		words = append(words, b.split(w))
+		freqs = append(freqs, freq)
+	}
+
+	// the initial vocabulary is made of the special tokens and every symbol, in sorted order
+	vocab, err := Construct(WithSize(vocabSize))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range b.specials {
+		vocab.AddN(s, 0)
+	}
+	var alphabet []string
+	seen := make(map[string]struct{})
+	for _, symbols := range words {
+		for _, s := range symbols {
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				alphabet = append(alphabet, s)
+			}
+		}
+	}
+	sort.Strings(alphabet)
+	for _, s := range alphabet {
+		vocab.AddN(s, 0)
+	}
+	if vocab.Size() > vocabSize {
+		return nil, errors.Errorf("Cannot train BPE to a vocabulary of %d. There are already %d special tokens and symbols", vocabSize, vocab.Size())
+	}
+
+	t := newBPETrainer(words, freqs)
+	for vocab.Size() < vocabSize {
+		m, ok := t.best()
+		if !ok {
+			break
+		}
+		t.merge(m)
+		b.ranks[m] = len(b.merges)
+		b.merges = append(b.merges, m)
+		vocab.AddN(m.Left+m.Right, 0)
+	}
+
+	// the frequency of each subword is how often it occurs in the encoded training words
+	for i, symbols := range t.words {
+		for _, s := range symbols {
+			vocab.AddN(s, t.freqs[i])
+		}
+	}
+	b.vocab = vocab
+	return b, nil
+}
+
+// Vocab returns the subword vocabulary.
+func (b *BPE) Vocab() Vocabulary { return b.vocab }
+
+// Merges returns the merges, in order of priority. The returned slice must not be modified.
+func (b *BPE) Merges() []Merge { return b.merges }
+
+// EndOfWord returns the end of word marker.
+func (b *BPE) EndOfWord() string { return b.endOfWord }
+
+// Encode splits a word into subwords by applying the merges in order of priority. Results are cached, and the returned slice is a copy
+// that may be modified. The cache holds up to maxBPECache words, and is emptied when it is full.
+// Special tokens are returned as they are.
+func (b *BPE) Encode(word string) []string {
+	b.mu.RLock()
+	cached, ok := b.cache[word]
+	b.mu.RUnlock()
+	if !ok {
+		if b.isSpecial(word) {
+			cached = []string{word}
+		} else {
+			cached = b.apply(b.split(word), func(Merge) bool { return true })
+		}
+
+		b.mu.Lock()
+		if len(b.cache) >= maxBPECache {
+			b.cache = make(map[string][]string)
+		}
+		b.cache[word] = cached
+		b.mu.Unlock()
+	}
+
+	retVal := make([]string, len(cached))
+	copy(retVal, cached)
+	return retVal
+}
+
+// EncodeIDs splits a word into subwords, and returns their IDs. Subwords missing from the vocabulary are encoded as the unknown token.
+func (b *BPE) EncodeIDs(word string) []int {
+	return b.ids(b.Encode(word))
+}
+
+// EncodeTokens splits every token into subwords, which keep the offsets of the parts of the token they cover. See Token.
+func (b *BPE) EncodeTokens(tokens []Token) []Token {
+	retVal := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		subwords := b.Encode(t.Text)
+		retVal = append(retVal, concatTokens(t, subwords, b.ids(subwords), 0)...)
+	}
+	return retVal
+}
+
+// EncodeDropout splits a word into subwords like Encode, but skips every merge it could apply with probability p, as in BPE-dropout
+// (Provilkov et al., 2020). This gives varied segmentations of the same word for training. When p is 0, the result is that of Encode; when p is 1,
+// the word is split into its initial symbols. Results are not cached.
+func (b *BPE) EncodeDropout(word string, p float64, rng *rand.Rand) []string {
+	if b.isSpecial(word) {
+		return []string{word}
+	}
+	return b.apply(b.split(word), func(Merge) bool { return rng.Float64() >= p })
+}
+
+// EncodeDropoutIDs is like EncodeDropout, but returns the IDs of the subwords.
+func (b *BPE) EncodeDropoutIDs(word string, p float64, rng *rand.Rand) []int {
+	return b.ids(b.EncodeDropout(word, p, rng))
+}
+
+// Decode joins subwords back into text. End of word markers become spaces.
+func (b *BPE) Decode(subwords []string) string {
+	s := strings.Join(subwords, "")
+	if b.endOfWord != "" {
+		s = strings.TrimRight(strings.ReplaceAll(s, b.endOfWord, " "), " ")
+	}
+	return s
+}
+
+// ids looks up the IDs of subwords, falling back on the unknown token.
+func (b *BPE) ids(subwords []string) []int {
+	unk, ok := b.vocab.Id(b.unknown)
+	if !ok || b.unknown == "" {
+		unk = -1
+	}
+	retVal := make([]int, len(subwords))
+	for i, s := range subwords {
+		id, ok := b.vocab.Id(s)
+		if !ok {
+			id = unk
+		}
+		retVal[i] = id
+	}
+	return retVal
+}
+
+func (b *BPE) isSpecial(word string) bool {
+	for _, s := range b.specials {
+		if s == word {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRunes splits a word into runes, and appends the end of word marker to the last one.
+func (b *BPE) splitRunes(word string) []string {
+	symbols := make([]string, 0, len(word))
+	for _, r := range word {
+		symbols = append(symbols, string(r))
+	}
+	if len(symbols) > 0 {
+		symbols[len(symbols)-1] += b.endOfWord
+	}
+	return symbols
+}
+
+// apply repeatedly merges the leftmost adjacent pair of symbols with the lowest rank, until no pair can be merged.
+// allow is asked about every pair that could be merged, and may veto it (this is how BPE-dropout is done).
+func (b *BPE) apply(symbols []string, allow func(Merge) bool) []string {
+	for len(symbols) > 1 {
+		best, at := -1, -1
+		for i := 0; i < len(symbols)-1; i++ {
+			m := Merge{symbols[i], symbols[i+1]}
+			rank, ok := b.ranks[m]
+			if !ok || (best >= 0 && rank >= best) || !allow(m) {
+				continue
+			}
+			best, at = rank, i
+		}
+		if at < 0 {
+			break
+		}
+
+		symbols[at] += symbols[at+1]
+		symbols = append(symbols[:at+1], symbols[at+2:]...)
+	}
+	return symbols
+}
+
+// bpeTrainer keeps the counts of adjacent pairs of symbols up to date as merges are applied to the training words.
+type bpeTrainer struct {
+	words [][]string
+	freqs []int
+
+	counts map[Merge]int
+	where  map[Merge]map[int]struct{} // the indices of the words in which each pair occurs
+	queue  pairQueue
+}
+
+func newBPETrainer(words [][]string, freqs []int) *bpeTrainer {
+	t := &bpeTrainer{
+		words:  words,
+		freqs:  freqs,
+		counts: make(map[Merge]int),
+		where:  make(map[Merge]map[int]struct{}),
+	}
+	for i := range words {
+		t.count(i, 1)
+	}
+	for m, count := range t.counts {
+		t.queue = append(t.queue, pairCount{m, count})
+	}
+	heap.Init(&t.queue)
+	return t
+}
+
+// count adds (sign = 1) or removes (sign = -1) the pairs of the ith word to the counts.
+func (t *bpeTrainer) count(i, sign int) {
+	symbols := t.words[i]
+	for j := 0; j < len(symbols)-1; j++ {
+		m := Merge{symbols[j], symbols[j+1]}
+		t.counts[m] += sign * t.freqs[i]
+		if sign > 0 {
+			if t.where[m] == nil {
+				t.where[m] = make(map[int]struct{})
+			}
+			t.where[m][i] = struct{}{}
+		}
+	}
+}
+
+// best returns the most frequent pair. Ties are broken by the order of the symbols, so training is deterministic.
+func (t *bpeTrainer) best() (Merge, bool) {
+	for t.queue.Len() > 0 {
+		top := heap.Pop(&t.queue).(pairCount)
+		// the queue is lazily updated, so stale entries are skipped
+		if current := t.counts[top.pair]; current != top.count {
+			continue
+		}
+		if top.count < 1 {
+			return Merge{}, false
+		}
+		return top.pair, true
+	}
+	return Merge{}, false
+}
+
+// merge applies the merge to every training word containing the pair, updating the counts of the affected pairs.
+func (t *bpeTrainer) merge(m Merge) {
+	touched := make(map[Merge]struct{})
+	for i := range t.where[m] {
+		symbols := t.words[i]
+		for j := 0; j < len(symbols)-1; j++ {
+			touched[Merge{symbols[j], symbols[j+1]}] = struct{}{}
+		}
+		t.count(i, -1)
+
+		merged := make([]string, 0, len(symbols))
+		for j := 0; j < len(symbols); j++ {
+			if j < len(symbols)-1 && symbols[j] == m.Left && symbols[j+1] == m.Right {
+				merged = append(merged, m.Left+m.Right)
+				j++
+				continue
+			}
+			merged = append(merged, symbols[j])
+		}
+		t.words[i] = merged
+
+		t.count(i, 1)
+		for j := 0; j < len(merged)-1; j++ {
+			touched[Merge{merged[j], merged[j+1]}] = struct{}{}
+		}
+	}
+	delete(t.where, m)
+	delete(t.counts, m)
+
+	for p := range touched {
+		if count, ok := t.counts[p]; ok && p != m {
+			heap.Push(&t.queue, pairCount{p, count})
+		}
+	}
+}
+
+type pairCount struct {
+	pair  Merge
+	count int
+}
+
+// pairQueue is a max heap of pairs by count, and then by the order of the pair's symbols.
+type pairQueue []pairCount
+
+func (q pairQueue) Len() int { return len(q) }
+func (q pairQueue) Less(i, j int) bool {
+	if q[i].count != q[j].count {
+		return q[i].count > q[j].count
+	}
+	if q[i].pair.Left != q[j].pair.Left {
+		return q[i].pair.Left < q[j].pair.Left
+	}
+	return q[i].pair.Right < q[j].pair.Right
+}
+func (q pairQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pairQueue) Push(x interface{}) { *q = append(*q, x.(pairCount)) }
+func (q *pairQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[:n-1]
+	return x
+}
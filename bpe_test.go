@@ -0,0 +1,195 @@
+package corpus
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sennrich returns the toy corpus of Sennrich et al. (2016), Neural Machine Translation of Rare Words with Subword Units.
+func sennrich() *Corpus {
+	c := New()
+	c.AddN("low", 5)
+	c.AddN("lower", 2)
+	c.AddN("newest", 6)
+	c.AddN("widest", 3)
+	return c
+}
+
+func TestTrainBPE(t *testing.T) {
+	assert := assert.New(t)
+	b, err := TrainBPE(sennrich(), 16, WithEndOfWord("</w>"), WithSpecialTokens("<pad>"))
+	require.NoError(t, err)
+
+	// <pad>, -UNKNOWN-, and the 11 initial symbols leave room for 3 merges
+	assert.Equal([]Merge{{"e", "s"}, {"es", "t</w>"}, {"l", "o"}}, b.Merges())
+	assert.Equal(16, b.Vocab().Size())
+	assert.Equal("</w>", b.EndOfWord())
+
+	id, ok := b.Vocab().Id("<pad>")
+	assert.True(ok)
+	assert.Equal(0, id)
+	id, ok = b.Vocab().Id("-UNKNOWN-")
+	assert.True(ok)
+	assert.Equal(1, id)
+	id, ok = b.Vocab().Id("lo")
+	assert.True(ok)
+	assert.Equal(15, id)
+
+	// frequencies are those of the subwords in the encoded training words
+	assert.Equal(9, b.Vocab().WordFreq("est</w>"))
+	assert.Equal(7, b.Vocab().WordFreq("lo"))
+	assert.Equal(0, b.Vocab().WordFreq("s"))
+	assert.Equal(0, b.Vocab().WordFreq("<pad>"))
+	vocab, ok := b.Vocab().(*Corpus)
+	require.True(t, ok)
+	assert.NoError(vocab.Validate())
+}
+
+func TestTrainBPE_Vocabulary(t *testing.T) {
+	assert := assert.New(t)
+	v := newMapVocab()
+	v.AddN("low", 5)
+	v.AddN("lower", 2)
+	v.AddN("newest", 6)
+	v.AddN("widest", 3)
+
+	want, err := TrainBPE(sennrich(), 16, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+	b, err := TrainBPE(v, 16, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+	assert.Equal(want.Merges(), b.Merges())
+	assert.Equal(want.Encode("lowest"), b.Encode("lowest"))
+
+	b = NewBPE(v, []Merge{{"l", "o"}, {"lo", "w"}})
+	id, _ := v.Id("low")
+	assert.Equal([]int{id}, b.EncodeIDs("low"))
+}
+
+func TestBPE_EncodeCopy(t *testing.T) {
+	b, err := TrainBPE(sennrich(), 16, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+
+	// modifying an encoding doesn't change the cached one
+	subwords := b.Encode("newest")
+	want := append([]string(nil), subwords...)
+	subwords[0] = "x"
+	assert.Equal(t, want, b.Encode("newest"))
+	b.Encode("newest")[0] = "x"
+	assert.Equal(t, want, b.Encode("newest"))
+}
+
+func TestTrainBPE_Exhausted(t *testing.T) {
+	assert := assert.New(t)
+	b, err := TrainBPE(sennrich(), 1000, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+
+	// every training word ends up as a single subword
+	for _, w := range []string{"low", "lower", "newest", "widest"} {
+		assert.Equal([]string{w + "</w>"}, b.Encode(w))
+	}
+	assert.Less(b.Vocab().Size(), 1000)
+
+	_, err = TrainBPE(sennrich(), 5)
+	assert.Error(err)
+}
+
+func TestBPE_Encode(t *testing.T) {
+	assert := assert.New(t)
+	b, err := TrainBPE(sennrich(), 1000, WithEndOfWord("</w>"), WithSpecialTokens("<s>"))
+	require.NoError(t, err)
+
+	assert.Equal([]string{"low", "est</w>"}, b.Encode("lowest"))
+	assert.Equal([]string{"<s>"}, b.Encode("<s>"))
+	assert.Equal([]string{}, b.Encode(""))
+
+	// cached results are the same
+	assert.Equal([]string{"low", "est</w>"}, b.Encode("lowest"))
+
+	unk, _ := b.Vocab().Id("-UNKNOWN-")
+	low, _ := b.Vocab().Id("low")
+	ids := b.EncodeIDs("lowz")
+	assert.Equal([]int{low, unk}, ids)
+
+	var words []string
+	for _, w := range []string{"the", "lowest", "newer"} {
+		words = append(words, b.Encode(w)...)
+	}
+	assert.Equal("the lowest newer", b.Decode(words))
+}
+
+func TestBPE_Encode_Concurrent(t *testing.T) {
+	b, err := TrainBPE(sennrich(), 1000, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, w := range []string{"lowest", "newer", "wider", "lowest"} {
+				b.Encode(w)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"low", "est</w>"}, b.Encode("lowest"))
+}
+
+func TestBPE_EncodeDropout(t *testing.T) {
+	assert := assert.New(t)
+	b, err := TrainBPE(sennrich(), 1000, WithEndOfWord("</w>"), WithSpecialTokens("<s>"))
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(1337))
+
+	assert.Equal(b.Encode("lowest"), b.EncodeDropout("lowest", 0, rng))
+	assert.Equal([]string{"l", "o", "w", "e", "s", "t</w>"}, b.EncodeDropout("lowest", 1, rng))
+	assert.Equal([]string{"<s>"}, b.EncodeDropout("<s>", 1, rng))
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		subwords := b.EncodeDropout("newest", 0.3, rng)
+		assert.Equal("newest", b.Decode(subwords))
+		seen[strings.Join(subwords, " ")] = struct{}{}
+	}
+	assert.True(len(seen) > 2, "%v", seen)
+
+	ids := b.EncodeDropoutIDs("lowz", 1, rng)
+	unk, _ := b.Vocab().Id("-UNKNOWN-")
+	l, _ := b.Vocab().Id("l")
+	assert.Equal(l, ids[0])
+	assert.Equal(unk, ids[3])
+
+	// the cache is untouched
+	assert.Equal([]string{"newest</w>"}, b.Encode("newest"))
+}
+
+func TestNewBPE(t *testing.T) {
+	assert := assert.New(t)
+	vocab, err := Construct(WithOrderedWords([]string{"-UNKNOWN-", "h", "u", "g", "hu", "hug"}))
+	require.NoError(t, err)
+	b := NewBPE(vocab, []Merge{{"h", "u"}, {"hu", "g"}})
+
+	assert.Equal([]string{"hug", "hug"}, b.Encode("hughug"))
+	assert.Equal([]int{5, 3}, b.EncodeIDs("hugg"))
+	assert.Equal([]int{1, 0}, b.EncodeIDs("hx"))
+}
+
+func TestBPE_EncodeTokens(t *testing.T) {
+	assert := assert.New(t)
+	b, err := TrainBPE(sennrich(), 1000, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+	low, _ := b.Vocab().Id("low")
+	est, _ := b.Vocab().Id("est</w>")
+
+	tokens := b.EncodeTokens(AlignTokens("so lowest", []string{"so", "lowest"}))
+	assert.Len(tokens, 4)
+	assert.Equal(Token{Text: "low", Start: 3, End: 6, RuneStart: 3, RuneEnd: 6, ID: low}, tokens[2])
+	assert.Equal(Token{Text: "est</w>", Start: 6, End: 9, RuneStart: 6, RuneEnd: 9, ID: est}, tokens[3])
+	assert.Equal(0, tokens[0].Start)
+	assert.Equal(2, tokens[1].End)
+}
@@ -0,0 +1,301 @@
+package corpus
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// byteEncoder maps every byte to a printable rune, the way GPT-2 does: printable Latin-1 bytes map to themselves, and the rest
+// (control characters and whitespace, mostly) map to the runes from U+0100 on. byteDecoder is the reverse.
+var byteEncoder, byteDecoder = makeByteTables()
+
+func makeByteTables() (enc [256]rune, dec map[rune]byte) {
+	dec = make(map[rune]byte, 256)
+	n := 0
+	for b := 0; b < 256; b++ {
+		printable := (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF)
+		if printable {
+			enc[b] = rune(b)
+		} else {
+			enc[b] = rune(256 + n)
+			n++
+		}
+		dec[enc[b]] = byte(b)
+	}
+	return enc, dec
+}
+
+// gpt2Contractions are the contractions GPT-2 splits off words. Like GPT-2, only their lowercase forms are recognized.
+var gpt2Contractions = []string{"'s", "'t", "'re", "'ve", "'m", "'ll", "'d"}
+
+// ByteLevelBPE is the byte-level BPE tokenizer of GPT-2, RoBERTa and the models derived from them.
+//
+// Text is pre-tokenized into words (with the space before a word attached to it), the UTF-8 bytes of every word are mapped to printable runes,
+// and the merges are applied to those. As every byte is in the vocabulary, there are no unknown tokens, and decoding gives back the exact text.
+type ByteLevelBPE struct {
+	bpe      *BPE
+	specials []string
+}
+
+// LoadByteLevelBPE loads a GPT-2 style byte-level BPE model from its vocab.json (a JSON object of tokens to IDs) and merges.txt (one merge per line,
+// as two tokens separated by a space, in order of priority, optionally preceded by a "#version" line).
+//
+// The special tokens given as options, such as "<|endoftext|>", are matched in text before pre-tokenization, and are encoded as single tokens.
+// By default there is no unknown token.
+func LoadByteLevelBPE(vocabJSON, mergesTxt io.Reader, opts ...BPEOpt) (*ByteLevelBPE, error) {
+	var ids map[string]int
+	if err := json.NewDecoder(vocabJSON).Decode(&ids); err != nil {
+		return nil, errors.Wrap(err, "Cannot decode vocab.json")
+	}
+	vocab, err := Construct(FromDict(ids))
+	if err != nil {
+		return nil, err
+	}
+	merges, err := readMerges(mergesTxt)
+	if err != nil {
+		return nil, err
+	}
+	return NewByteLevelBPE(vocab, merges, opts...), nil
+}
+
+// NewByteLevelBPE creates a byte-level BPE tokenizer from a vocabulary of tokens and a merge list, in order of priority.
+// The tokens are made of the runes that bytes map to.
+func NewByteLevelBPE(vocab Vocabulary, merges []Merge, opts ...BPEOpt) *ByteLevelBPE {
+	b := NewBPE(vocab, merges, append([]BPEOpt{WithUnknownToken("")}, opts...)...)
+	b.split = byteSymbols
+	return &ByteLevelBPE{
+		bpe:      b,
+		specials: b.specials,
+	}
+}
+
+// readMerges reads a merges.txt.
+func readMerges(r io.Reader) ([]Merge, error) {
+	var merges []Merge
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (lineNum == 1 && strings.HasPrefix(line, "#version")) || line == "" {
+			continue
+		}
+		parts := strings.Split(line, " ")
+		if len(parts) != 2 {
+			return nil, errors.Errorf("Cannot parse merge on line %d: %q. Expected two tokens separated by a space", lineNum, line)
+		}
+		merges = append(merges, Merge{parts[0], parts[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Cannot read merges")
+	}
+	return merges, nil
+}
+
+// Vocab returns the vocabulary of tokens.
+func (b *ByteLevelBPE) Vocab() Vocabulary { return b.bpe.Vocab() }
+
+// Merges returns the merges, in order of priority. The returned slice must not be modified.
+func (b *ByteLevelBPE) Merges() []Merge { return b.bpe.Merges() }
+
+// Tokenize splits text into tokens. Special tokens are kept as they are; all other tokens are made of the runes that bytes map to (so " world" becomes "Ġworld").
+func (b *ByteLevelBPE) Tokenize(text string) []string {
+	retVal := make([]string, 0)
+	for _, piece := range splitSpecials(text, b.specials) {
+		if piece.special {
+			retVal = append(retVal, piece.text)
+			continue
+		}
+		for _, w := range PreTokenizeGPT2(piece.text) {
+			retVal = append(retVal, b.bpe.Encode(w)...)
+		}
+	}
+	return retVal
+}
+
+// Tokens splits text into tokens with their IDs and offsets. See Token. Start and End are the offsets of the bytes a token holds, so a token that
+// holds only some of the bytes of a rune starts or ends inside it, and text[Start:End] isn't valid UTF-8. RuneStart and RuneEnd are widened to span the whole rune.
+func (b *ByteLevelBPE) Tokens(text string) []Token {
+	retVal := make([]Token, 0)
+	pos, runePos := 0, 0
+	add := func(w string, subwords []string) {
+		parent := Token{Text: w, Start: pos, End: pos + len(w), RuneStart: runePos, RuneEnd: runePos + utf8.RuneCountInString(w)}
+		ids := b.bpe.ids(subwords)
+		at := 0
+		for i, s := range subwords {
+			// every rune of a subword is a byte of the text, except in special tokens
+			n := len(w)
+			if !b.bpe.isSpecial(s) {
+				n = utf8.RuneCountInString(s)
+			}
+			retVal = append(retVal, subToken(parent, s, ids[i], at, at+n))
+			at += n
+		}
+		pos, runePos = parent.End, parent.RuneEnd
+	}
+
+	for _, piece := range splitSpecials(text, b.specials) {
+		if piece.special {
+			add(piece.text, []string{piece.text})
+			continue
+		}
+		for _, w := range PreTokenizeGPT2(piece.text) {
+			add(w, b.bpe.Encode(w))
+		}
+	}
+	return retVal
+}
+
+// Encode splits text into tokens, and returns their IDs. Tokens missing from the vocabulary (which only happens with incomplete vocabularies) are -1.
+func (b *ByteLevelBPE) Encode(text string) []int {
+	return b.bpe.ids(b.Tokenize(text))
+}
+
+// Decode turns token IDs back into text. It returns an error if an ID is not in the vocabulary.
+func (b *ByteLevelBPE) Decode(ids []int) (string, error) {
+	tokens := make([]string, len(ids))
+	for i, id := range ids {
+		w, ok := b.bpe.vocab.Word(id)
+		if !ok {
+			return "", errors.Errorf("Cannot decode ID %d at %d. It is not in the vocabulary", id, i)
+		}
+		tokens[i] = w
+	}
+	return b.DecodeTokens(tokens), nil
+}
+
+// DecodeTokens turns tokens back into text. Special tokens are kept as they are. Runes that no byte maps to are kept too.
+func (b *ByteLevelBPE) DecodeTokens(tokens []string) string {
+	buf := make([]byte, 0, len(tokens)*4)
+	for _, t := range tokens {
+		if b.bpe.isSpecial(t) {
+			buf = append(buf, t...)
+			continue
+		}
+		for _, r := range t {
+			if c, ok := byteDecoder[r]; ok {
+				buf = append(buf, c)
+			} else {
+				buf = utf8.AppendRune(buf, r)
+			}
+		}
+	}
+	return string(buf)
+}
+
+// byteSymbols maps every byte of a word to its rune.
+func byteSymbols(word string) []string {
+	symbols := make([]string, len(word))
+	for i := 0; i < len(word); i++ {
+		symbols[i] = string(byteEncoder[word[i]])
+	}
+	return symbols
+}
+
+type specialSplit struct {
+	text    string
+	special bool
+}
+
+// splitSpecials splits text at occurrences of the special tokens. Where several special tokens occur at the same place, the longest wins.
+func splitSpecials(text string, specials []string) []specialSplit {
+	var retVal []specialSplit
+	for len(text) > 0 {
+		at, match := -1, ""
+		for _, s := range specials {
+			if s == "" {
+				continue
+			}
+			i := strings.Index(text, s)
+			if i >= 0 && (at < 0 || i < at || (i == at && len(s) > len(match))) {
+				at, match = i, s
+			}
+		}
+		if at < 0 {
+			retVal = append(retVal, specialSplit{text, false})
+			break
+		}
+		if at > 0 {
+			retVal = append(retVal, specialSplit{text[:at], false})
+		}
+		retVal = append(retVal, specialSplit{match, true})
+		text = text[at+len(match):]
+	}
+	return retVal
+}
+
+// PreTokenizeGPT2 splits text into words the way GPT-2's pre-tokenization regular expression does:
+//
+//	's|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+
+//
+// Letters, numbers and other non-space runes make up separate words, each of which takes the space before it, if any. Contractions are split off.
+// Runs of whitespace are words of their own, except for the last space before a word. The words cover the text, so joining them gives it back.
+func PreTokenizeGPT2(text string) []string {
+	retVal := make([]string, 0)
+	for len(text) > 0 {
+		n := gpt2Match(text)
+		retVal = append(retVal, text[:n])
+		text = text[n:]
+	}
+	return retVal
+}
+
+type gpt2Class int
+
+const (
+	gpt2Space gpt2Class = iota
+	gpt2Letter
+	gpt2Number
+	gpt2Other
+)
+
+func gpt2ClassOf(r rune) gpt2Class {
+	switch {
+	case unicode.IsSpace(r):
+		return gpt2Space
+	case unicode.IsLetter(r):
+		return gpt2Letter
+	case unicode.IsNumber(r):
+		return gpt2Number
+	}
+	return gpt2Other
+}
+
+// gpt2Match returns the length in bytes of the word at the start of s.
+func gpt2Match(s string) int {
+	for _, c := range gpt2Contractions {
+		if strings.HasPrefix(s, c) {
+			return len(c)
+		}
+	}
+
+	start := 0
+	r, _ := utf8.DecodeRuneInString(s)
+	if r == ' ' && len(s) > 1 {
+		if next, _ := utf8.DecodeRuneInString(s[1:]); gpt2ClassOf(next) != gpt2Space {
+			start, r = 1, next
+		}
+	}
+
+	class := gpt2ClassOf(r)
+	end := start
+	var last int // the offset of the last rune of the run
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if gpt2ClassOf(r) != class {
+			break
+		}
+		last = end
+		end += size
+	}
+	if class != gpt2Space || end == len(s) || last == 0 {
+		return end
+	}
+	// a run of whitespace followed by a word leaves its last rune for the word
+	return last
+}
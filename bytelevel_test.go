@@ -0,0 +1,127 @@
+package corpus
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadGPT2(t *testing.T) *ByteLevelBPE {
+	vocab, err := os.Open("testdata/gpt2/vocab.json")
+	require.NoError(t, err)
+	defer vocab.Close()
+	merges, err := os.Open("testdata/gpt2/merges.txt")
+	require.NoError(t, err)
+	defer merges.Close()
+
+	b, err := LoadByteLevelBPE(vocab, merges, WithSpecialTokens("<|endoftext|>"))
+	require.NoError(t, err)
+	return b
+}
+
+func TestByteTables(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal('Ġ', byteEncoder[' '])
+	assert.Equal('Ċ', byteEncoder['\n'])
+	assert.Equal('!', byteEncoder['!'])
+	assert.Equal('Ā', byteEncoder[0])
+	assert.Equal(256, len(byteDecoder))
+	for b, r := range byteEncoder {
+		assert.Equal(byte(b), byteDecoder[r])
+	}
+}
+
+func TestPreTokenizeGPT2(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"Hello", " world", "!", " It", "'s", " 2024", "...", " ", " ok", "\n", "\n", "bye"}, PreTokenizeGPT2("Hello world! It's 2024...  ok\n\nbye"))
+	assert.Equal([]string{"hi", "  "}, PreTokenizeGPT2("hi  "))
+	assert.Equal([]string{" '", "s", " don", "'t"}, PreTokenizeGPT2(" 's don't"))
+	assert.Equal([]string{"I", "'", "M"}, PreTokenizeGPT2("I'M"))
+	assert.Equal([]string{"\t", " x", " 世界", "🙂"}, PreTokenizeGPT2("\t x 世界🙂"))
+	assert.Equal([]string{}, PreTokenizeGPT2(""))
+}
+
+func TestByteLevelBPE(t *testing.T) {
+	assert := assert.New(t)
+	b := loadGPT2(t)
+	assert.Equal(272, b.Vocab().Size())
+	assert.Equal(Merge{"Ġ", "t"}, b.Merges()[0])
+
+	assert.Equal([]string{"Hello", "Ġworld"}, b.Tokenize("Hello world"))
+	assert.Equal([]string{"t", "he", "<|endoftext|>", "Ġthe"}, b.Tokenize("the<|endoftext|> the"))
+	assert.Equal([]string{"ĠĠ", "Ġthe", "ĊĊ"}, b.Tokenize("   the\n\n"))
+	assert.Equal([]string{"h", "Ã", "©"}, b.Tokenize("hé"))
+
+	ids := b.Encode("Hello world<|endoftext|>")
+	hello, _ := b.Vocab().Id("Hello")
+	world, _ := b.Vocab().Id("Ġworld")
+	eot, _ := b.Vocab().Id("<|endoftext|>")
+	assert.Equal([]int{hello, world, eot}, ids)
+
+	_, err := b.Decode([]int{hello, 1000})
+	assert.Error(err)
+}
+
+func TestNewByteLevelBPE_Vocabulary(t *testing.T) {
+	assert := assert.New(t)
+	v := newMapVocab()
+	for _, w := range []string{"h", "i", "Ġ", "hi", "Ġhi"} {
+		v.Add(w)
+	}
+	b := NewByteLevelBPE(v, []Merge{{"h", "i"}, {"Ġ", "hi"}})
+	assert.Equal([]string{"hi", "Ġhi"}, b.Tokenize("hi hi"))
+
+	ids := b.Encode("hi hi")
+	assert.Equal([]int{3, 4}, ids)
+	text, err := b.Decode(ids)
+	require.NoError(t, err)
+	assert.Equal("hi hi", text)
+}
+
+func TestByteLevelBPE_RoundTrip(t *testing.T) {
+	b := loadGPT2(t)
+	texts := []string{
+		"Hello world",
+		"héllo 世界 🙂\t\n  x  ",
+		"It's the world's <|endoftext|>end",
+		"\xff\xfe invalid \x80 bytes",
+		"",
+		"   ",
+	}
+	for _, text := range texts {
+		s, err := b.Decode(b.Encode(text))
+		require.NoError(t, err)
+		assert.Equal(t, text, s)
+		assert.Equal(t, text, b.DecodeTokens(b.Tokenize(text)))
+	}
+}
+
+func TestByteLevelBPE_Tokens(t *testing.T) {
+	assert := assert.New(t)
+	b := loadGPT2(t)
+
+	tokens := b.Tokens("Hello world<|endoftext|>")
+	assert.Equal(b.Tokenize("Hello world<|endoftext|>"), []string{tokens[0].Text, tokens[1].Text, tokens[2].Text})
+	assert.Equal(b.Encode("Hello world<|endoftext|>"), TokenIDs(tokens))
+	assert.Equal([][2]int{{0, 5}, {5, 11}, {11, 24}}, [][2]int{{tokens[0].Start, tokens[0].End}, {tokens[1].Start, tokens[1].End}, {tokens[2].Start, tokens[2].End}})
+
+	// the bytes of é are split: the byte offsets of each token are its own, and its rune offsets span the rune
+	tokens = b.Tokens("hé")
+	assert.Equal([]Token{
+		{Text: "h", Start: 0, End: 1, RuneStart: 0, RuneEnd: 1, ID: tokens[0].ID},
+		{Text: "Ã", Start: 1, End: 2, RuneStart: 1, RuneEnd: 2, ID: tokens[1].ID},
+		{Text: "©", Start: 2, End: 3, RuneStart: 1, RuneEnd: 2, ID: tokens[2].ID},
+	}, tokens)
+
+	// the tokens of any text cover it, in order
+	for _, text := range []string{"héllo 世界 🙂\t\n  x  ", "It's the world's <|endoftext|>end", "\xff\xfe invalid \x80 bytes"} {
+		pos := 0
+		for _, tok := range b.Tokens(text) {
+			assert.Equal(pos, tok.Start, "%q", text)
+			pos = tok.End
+		}
+		assert.Equal(len(text), pos)
+	}
+}
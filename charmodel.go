@@ -0,0 +1,155 @@
+package corpus
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// UnknownModel gives the log probability of words that are not in a vocabulary. It is used by ViterbiSegment to score
+// out-of-vocabulary substrings. See WithUnknownModel.
+type UnknownModel interface {
+	UnknownLogProb(word string) float64
+}
+
+// WithUnknownModel makes ViterbiSegment score out-of-vocabulary words with the given model, instead of the default length-based penalty.
+// The model is given the lowercased word.
+func WithUnknownModel(m UnknownModel) SegmentOpt {
+	return func(s *segmenter) { s.unknown = m }
+}
+
+const (
+	charBOW = '\u0002' // marks the beginning of a word in a CharModel
+	charEOW = '\u0003' // marks the end of a word in a CharModel
+)
+
+// CharModel is a character n-gram language model over the spelling of words. It is trained from the words of a vocabulary,
+// and gives a principled probability to words that are not in the vocabulary.
+//
+// Probabilities are smoothed with Witten-Bell interpolation, backing off to a uniform distribution over the runes seen in training
+// (plus one, for runes never seen).
+type CharModel struct {
+	order    int
+	contexts map[string]*charContext // keyed by the preceding runes, from 0 up to order-1 of them
+
+	// unknownLogProb is the log probability that a word is not in the vocabulary at all.
+	unknownLogProb float64
+}
+
+type charContext struct {
+	total float64
+	next  map[rune]float64
+}
+
+// TrainCharModel trains a character n-gram model of the given order (3 is a reasonable choice) from the words of a vocabulary.
+//
+// Each word is counted with a weight of 1 + ln(freq), so that frequent words count for more without swamping the model:
+// unknown words look more like rare words than like "the".
+// The probability of a word being unknown is estimated as the proportion of words seen exactly once (Good-Turing), capped at 1/2.
+func TrainCharModel(v Vocabulary, order int) *CharModel {
+	if order < 1 {
+		order = 1
+	}
+	m := &CharModel{
+		order:    order,
+		contexts: make(map[string]*charContext),
+	}
+
+	var singletons int
+	for id := 0; id < v.Size(); id++ {
+		w, _ := v.Word(id)
+		if isSpecialIn(v, w) {
+			continue
+		}
+		freq := v.WordFreq(w)
+		if freq < 1 {
+			continue
+		}
+		if freq == 1 {
+			singletons++
+		}
+		m.add(w, 1+math.Log(float64(freq)))
+	}
+
+	total := v.TotalFreq()
+	switch {
+	case total < 1:
+		m.unknownLogProb = 0
+	case singletons == 0:
+		m.unknownLogProb = math.Log(1 / float64(total))
+	default:
+		m.unknownLogProb = math.Log(math.Min(float64(singletons)/float64(total), 0.5))
+	}
+	return m
+}
+
+// add counts the runes of a word with the given weight.
+func (m *CharModel) add(word string, weight float64) {
+	m.walk(word, func(ctx string, r rune) {
+		// count the rune under every suffix of its context, including the empty one
+		for {
+			c, ok := m.contexts[ctx]
+			if !ok {
+				c = &charContext{next: make(map[rune]float64)}
+				m.contexts[ctx] = c
+			}
+			c.total += weight
+			c.next[r] += weight
+			if ctx == "" {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(ctx)
+			ctx = ctx[size:]
+		}
+	})
+}
+
+// walk calls fn with every rune of the word (followed by the end of word marker) and the order-1 runes preceding it.
+// The word is padded with beginning of word markers, so every context has exactly order-1 runes.
+func (m *CharModel) walk(word string, fn func(ctx string, r rune)) {
+	padded := make([]rune, 0, m.order+len(word))
+	for i := 0; i < m.order-1; i++ {
+		padded = append(padded, charBOW)
+	}
+	padded = append(padded, []rune(word)...)
+	padded = append(padded, charEOW)
+
+	for i := m.order - 1; i < len(padded); i++ {
+		fn(string(padded[i-m.order+1:i]), padded[i])
+	}
+}
+
+// LogProb returns the log probability of the spelling of the word, given that it is a word.
+func (m *CharModel) LogProb(word string) float64 {
+	var lp float64
+	m.walk(word, func(ctx string, r rune) {
+		lp += math.Log(m.prob(ctx, r))
+	})
+	return lp
+}
+
+// UnknownLogProb returns the log probability of an unknown word: the probability of a word being unknown, times the probability of its spelling.
+func (m *CharModel) UnknownLogProb(word string) float64 {
+	return m.unknownLogProb + m.LogProb(word)
+}
+
+// prob is the Witten-Bell interpolated probability of the rune following the context.
+func (m *CharModel) prob(ctx string, r rune) float64 {
+	var lower float64
+	if ctx == "" {
+		alphabet := 1
+		if c, ok := m.contexts[""]; ok {
+			alphabet += len(c.next)
+		}
+		lower = 1 / float64(alphabet)
+	} else {
+		_, size := utf8.DecodeRuneInString(ctx)
+		lower = m.prob(ctx[size:], r)
+	}
+
+	c, ok := m.contexts[ctx]
+	if !ok {
+		return lower
+	}
+	types := float64(len(c.next))
+	return (c.next[r] + types*lower) / (c.total + types)
+}
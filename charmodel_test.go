@@ -0,0 +1,51 @@
+package corpus
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharModel(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"cat", "cat", "car", "cart", "dog"}))
+	require.NoError(t, err)
+	m := TrainCharModel(c, 3)
+
+	// the distribution following any context sums to 1
+	for _, ctx := range []string{"ca", "\u0002\u0002", "zz", "x"} {
+		var sum float64
+		for _, r := range []rune("catrdog\u0003") {
+			sum += m.prob(ctx, r)
+		}
+		sum += m.prob(ctx, 'q') // all unseen runes share the probability of one
+		assert.True(floatEquals64(1, sum), "context %q sums to %v", ctx, sum)
+	}
+
+	// things that look like the training words are more probable
+	assert.True(m.LogProb("cot") > m.LogProb("xqz"))
+	assert.True(m.LogProb("carts") > m.LogProb("ctars"))
+
+	// 3 of the 5 words were seen once
+	assert.True(floatEquals64(math.Log(0.5)+m.LogProb("xqz"), m.UnknownLogProb("xqz")))
+}
+
+func TestViterbiSegment_UnknownModel(t *testing.T) {
+	f, err := os.Open("testdata/corpus_en.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	dict, err := FromTextCorpus(f, nil, strings.ToLower)
+	require.NoError(t, err)
+	m := TrainCharModel(dict, 3)
+
+	assert.Equal(t, []string{"sn", "a", "r", "k", "hunting"}, ViterbiSplit("snarkhunting", dict))
+	seg := ViterbiSegment("snarkhunting", dict, WithUnknownModel(m))
+	assert.Equal(t, []string{"snark", "hunting"}, seg.Words)
+
+	dict.BuildTrie()
+	assert.Equal(t, seg, ViterbiSegment("snarkhunting", dict, WithUnknownModel(m)))
+}
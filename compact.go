@@ -0,0 +1,515 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"math"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// CompactCorpus is a memory-compact alternative to Corpus, meant for very large vocabularies (tens of millions of words).
+//
+// Where a Corpus stores every word twice (once as a map key and once in the word list), a CompactCorpus stores each word
+// exactly once in a single byte arena. Words are addressed by 32-bit offsets into the arena, and the lookup index is an
+// open-addressing hash table of 32-bit IDs. Frequencies are stored as 32-bit counts, with the rare counts that don't fit
+// kept on the side.
+//
+// A CompactCorpus can hold at most math.MaxInt32 words, and at most 4GiB worth of words. Word allocates a new string on every call.
+// It has the same methods as Corpus for building and maintaining the vocabulary, but Replace and ReplaceWord rewrite the arena,
+// so they take time proportional to the size of the corpus.
+type CompactCorpus struct {
+	arena   []byte
+	offsets []uint32 // offsets[i] is where the (i+1)th word ends. The first word starts at 0.
+	freqs   []uint32
+	big     map[int32]int // frequencies that do not fit in a uint32. The corresponding entry in freqs is math.MaxUint32
+
+	index   []uint32         // open addressing hash table of ID+1. 0 marks an empty slot. len(index) is always a power of 2.
+	aliases map[string]int32 // words that were replaced by Replace or ReplaceWord, which still refer to their IDs
+
+	totalFreq     int
+	maxWordLength int
+
+	trie *Trie
+}
+
+const minCompactIndexSize = 16
+
+// NewCompact creates a new *CompactCorpus with the same default words as New.
+func NewCompact() *CompactCorpus {
+	c := &CompactCorpus{
+		index: make([]uint32, minCompactIndexSize),
+		big:   make(map[int32]int),
+	}
+
+	// add some default words
+	c.Add("") // aka NULL - when there are no words
+	c.Add("-UNKNOWN-")
+	c.Add("-ROOT-")
+	c.maxWordLength = 0 // specials don't have lengths
+
+	return c
+}
+
+// Compact creates a *CompactCorpus that holds the same words, IDs and frequencies as the given *Corpus.
+func Compact(c *Corpus) *CompactCorpus {
+	cc := &CompactCorpus{
+		offsets: make([]uint32, 0, len(c.words)),
+		freqs:   make([]uint32, 0, len(c.words)),
+		big:     make(map[int32]int),
+	}
+	var arenaSize int
+	for _, w := range c.words {
+		arenaSize += len(w)
+	}
+	cc.arena = make([]byte, 0, arenaSize)
+	cc.index = make([]uint32, indexSizeFor(len(c.words)))
+
+	for i, w := range c.words {
+		cc.AddN(w, c.frequencies[i])
+	}
+	for w, id := range c.ids {
+		if c.words[id] != w {
+			cc.alias(w, id)
+		}
+	}
+	cc.totalFreq = c.totalFreq
+	cc.maxWordLength = c.maxWordLength
+	return cc
+}
+
+// Corpus converts the *CompactCorpus back into a *Corpus with the same words, IDs and frequencies.
+func (c *CompactCorpus) Corpus() *Corpus {
+	retVal := &Corpus{Vocab: makeVocab[string](c.Size())}
+	for i := 0; i < c.Size(); i++ {
+		w, _ := c.Word(i)
+		retVal.AddN(w, c.IDFreq(i))
+	}
+	for w, id := range c.aliases {
+		retVal.ids[w] = int(id)
+	}
+	retVal.totalFreq = c.totalFreq
+	retVal.maxWordLength = c.maxWordLength
+	return retVal
+}
+
+// Id returns the ID of a word and whether or not it was found in the corpus
+func (c *CompactCorpus) Id(word string) (int, bool) {
+	slot := c.find(word)
+	if slot < 0 || c.index[slot] == 0 {
+		id, ok := c.aliases[word]
+		return int(id), ok
+	}
+	return int(c.index[slot] - 1), true
+}
+
+// Word returns the word given the ID, and whether or not it was found in the corpus
+func (c *CompactCorpus) Word(id int) (string, bool) {
+	if id < 0 || id >= len(c.offsets) {
+		return "", false
+	}
+	return string(c.word(id)), true
+}
+
+// Add adds a word to the corpus and returns its ID. If a word was previously in the corpus, it merely updates the frequency count and returns the ID
+func (c *CompactCorpus) Add(word string) int { return c.AddN(word, 1) }
+
+// AddN adds n occurrences of a word to the corpus and returns its ID.
+func (c *CompactCorpus) AddN(word string, n int) int {
+	if id, ok := c.Id(word); ok {
+		c.setFreq(id, c.IDFreq(id)+n)
+		c.totalFreq += n
+		return id
+	}
+
+	id := len(c.offsets)
+	if id == math.MaxInt32 {
+		panic("CompactCorpus cannot hold more than math.MaxInt32 words")
+	}
+	if len(c.arena)+len(word) > math.MaxUint32 {
+		panic("CompactCorpus cannot hold more than 4GiB worth of words")
+	}
+
+	if len(c.index) == 0 {
+		c.index = make([]uint32, minCompactIndexSize)
+	}
+	c.arena = append(c.arena, word...)
+	c.offsets = append(c.offsets, uint32(len(c.arena)))
+	c.freqs = append(c.freqs, 0)
+	c.setFreq(id, n)
+	c.totalFreq += n
+	c.index[c.find(word)] = uint32(id + 1)
+	if c.trie != nil {
+		c.trie.Insert(word, id)
+	}
+
+	runeCount := utf8.RuneCountInString(word)
+	if runeCount > c.maxWordLength {
+		c.maxWordLength = runeCount
+	}
+
+	// keep the load factor of the index under 3/4
+	if len(c.offsets)*4 > len(c.index)*3 {
+		c.rehash(len(c.index) * 2)
+	}
+	return id
+}
+
+// Size returns the size of the corpus.
+func (c *CompactCorpus) Size() int { return len(c.offsets) }
+
+// WordFreq returns the frequency of the word. If the word wasn't in the corpus, it returns 0.
+func (c *CompactCorpus) WordFreq(word string) int {
+	id, ok := c.Id(word)
+	if !ok {
+		return 0
+	}
+	return c.IDFreq(id)
+}
+
+// IDFreq returns the frequency of a word given an ID. If the word isn't in the corpus it returns 0.
+func (c *CompactCorpus) IDFreq(id int) int {
+	if id < 0 || id >= len(c.freqs) {
+		return 0
+	}
+	f := c.freqs[id]
+	if f == math.MaxUint32 {
+		return c.big[int32(id)]
+	}
+	return int(f)
+}
+
+// TotalFreq returns the total number of words ever seen by the corpus. This number includes the count of repeat words.
+func (c *CompactCorpus) TotalFreq() int { return c.totalFreq }
+
+// MaxWordLength returns the length of the longest known word in the corpus.
+func (c *CompactCorpus) MaxWordLength() int { return c.maxWordLength }
+
+// WordProb returns the probability of a word appearing in the corpus.
+func (c *CompactCorpus) WordProb(word string) (float64, bool) {
+	id, ok := c.Id(word)
+	if !ok {
+		return 0, false
+	}
+	return float64(c.IDFreq(id)) / float64(c.totalFreq), true
+}
+
+// Merge combines two corpuses. The receiver is the one that is mutated.
+func (c *CompactCorpus) Merge(other *CompactCorpus) {
+	for id := 0; id < other.Size(); id++ {
+		word := string(other.word(id))
+		if _, ok := specialWords[word]; !ok {
+			c.AddN(word, other.IDFreq(id))
+			continue
+		}
+		// the special words added by NewCompact don't count towards the maximum word length
+		maxWordLength := c.maxWordLength
+		c.AddN(word, other.IDFreq(id))
+		c.maxWordLength = maxWordLength
+	}
+}
+
+// Prune removes all words whose frequency is less than minFreq, except for the words listed in keep.
+// The remaining words are assigned new, contiguous IDs. Note that the special words added by NewCompact are not kept unless listed.
+func (c *CompactCorpus) Prune(minFreq int, keep ...string) {
+	kept := make(map[string]struct{}, len(keep))
+	for _, w := range keep {
+		kept[w] = struct{}{}
+	}
+
+	arena := make([]byte, 0, len(c.arena))
+	offsets := make([]uint32, 0, len(c.offsets))
+	freqs := make([]uint32, 0, len(c.freqs))
+	big := make(map[int32]int)
+	var totalFreq, maxWordLength int
+	for id := range c.offsets {
+		word := c.word(id)
+		freq := c.IDFreq(id)
+		if _, ok := kept[string(word)]; !ok && freq < minFreq {
+			continue
+		}
+		if c.freqs[id] == math.MaxUint32 {
+			big[int32(len(offsets))] = c.big[int32(id)]
+		}
+		arena = append(arena, word...)
+		offsets = append(offsets, uint32(len(arena)))
+		freqs = append(freqs, c.freqs[id])
+		totalFreq += freq
+		if _, ok := specialWords[string(word)]; !ok {
+			if runeCount := utf8.RuneCount(word); runeCount > maxWordLength {
+				maxWordLength = runeCount
+			}
+		}
+	}
+
+	c.arena, c.offsets, c.freqs, c.big = arena, offsets, freqs, big
+	c.aliases = nil
+	c.totalFreq = totalFreq
+	c.maxWordLength = maxWordLength
+	c.rehash(indexSizeFor(len(offsets)))
+
+	if c.trie != nil {
+		c.BuildTrie()
+	}
+}
+
+// BuildTrie builds a prefix index over the words of the corpus and returns it. From then on, the index is kept in sync as words are added.
+// Calling BuildTrie again rebuilds the index from scratch.
+func (c *CompactCorpus) BuildTrie() *Trie {
+	c.trie = NewTrie(c)
+	return c.trie
+}
+
+// Trie returns the prefix index of the corpus, or nil if BuildTrie has never been called.
+func (c *CompactCorpus) Trie() *Trie { return c.trie }
+
+// Replace replaces the content of a word. The old reference remains.
+//
+// e.g: c.Replace("foo", "bar")
+// c.Id("foo") will still return a ID. The ID will be the same as c.Id("bar")
+func (c *CompactCorpus) Replace(a, with string) error {
+	id, ok := c.Id(a)
+	if !ok {
+		return errors.Errorf("Cannot replace %q with %q. %q is not found", a, with, a)
+	}
+	if _, ok := c.Id(with); ok {
+		return errors.Errorf("Cannot replace %q with %q. %q exists in the corpus", a, with, with)
+	}
+	return c.replace(id, with)
+}
+
+// ReplaceWord replaces the word associated with the given ID. The old reference remains.
+func (c *CompactCorpus) ReplaceWord(id int, with string) error {
+	if id < 0 || id >= len(c.offsets) {
+		return errors.Errorf("Cannot replace word with ID %d. Out of bounds.", id)
+	}
+	if _, ok := c.Id(with); ok {
+		return errors.Errorf("Cannot replace word with ID %d with %q. %q exists in the corpus", id, with, with)
+	}
+	return c.replace(id, with)
+}
+
+// replace writes the new word of an ID into the arena, keeping the old word as an alias.
+func (c *CompactCorpus) replace(id int, with string) error {
+	var start uint32
+	if id > 0 {
+		start = c.offsets[id-1]
+	}
+	end := c.offsets[id]
+	delta := len(with) - int(end-start)
+	if len(c.arena)+delta > math.MaxUint32 {
+		return errors.Errorf("Cannot replace word with ID %d with %q. CompactCorpus cannot hold more than 4GiB worth of words", id, with)
+	}
+
+	c.alias(string(c.word(id)), id)
+	arena := make([]byte, 0, len(c.arena)+delta)
+	arena = append(arena, c.arena[:start]...)
+	arena = append(arena, with...)
+	c.arena = append(arena, c.arena[end:]...)
+	for i := id; i < len(c.offsets); i++ {
+		c.offsets[i] = uint32(int(c.offsets[i]) + delta)
+	}
+	c.rehash(len(c.index))
+
+	if runeCount := utf8.RuneCountInString(with); runeCount > c.maxWordLength {
+		c.maxWordLength = runeCount
+	}
+	if c.trie != nil {
+		c.trie.Insert(with, id)
+	}
+	return nil
+}
+
+// alias makes a word that isn't in the arena refer to the given ID.
+func (c *CompactCorpus) alias(word string, id int) {
+	if c.aliases == nil {
+		c.aliases = make(map[string]int32)
+	}
+	c.aliases[word] = int32(id)
+}
+
+// GobEncode implements GobEncoder for *CompactCorpus. The hash index is not encoded; it is rebuilt when decoding.
+func (c *CompactCorpus) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+
+	if err := encoder.Encode(c.arena); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Encode(c.offsets); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Encode(c.freqs); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Encode(c.big); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Encode(c.totalFreq); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Encode(c.maxWordLength); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Encode(c.aliases); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements GobDecoder for *CompactCorpus
+func (c *CompactCorpus) GobDecode(buf []byte) error {
+	b := bytes.NewBuffer(buf)
+	decoder := gob.NewDecoder(b)
+
+	c.arena, c.offsets, c.freqs, c.big = nil, nil, nil, make(map[int32]int)
+	if err := decoder.Decode(&c.arena); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&c.offsets); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&c.freqs); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&c.big); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&c.totalFreq); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&c.maxWordLength); err != nil {
+		return err
+	}
+
+	// corpuses encoded before aliases were added end here
+	c.aliases = nil
+	if err := decoder.Decode(&c.aliases); err != nil && err != io.EOF {
+		return err
+	}
+	if len(c.aliases) == 0 {
+		c.aliases = nil
+	}
+
+	if len(c.offsets) != len(c.freqs) {
+		return errors.Errorf("Corrupted CompactCorpus. %d offsets but %d frequencies", len(c.offsets), len(c.freqs))
+	}
+	var start uint32
+	for i, end := range c.offsets {
+		if end < start || int(end) > len(c.arena) {
+			return errors.Errorf("Corrupted CompactCorpus. Offset of word %d (%d) is out of bounds", i, end)
+		}
+		start = end
+	}
+	for w, id := range c.aliases {
+		if id < 0 || int(id) >= len(c.offsets) {
+			return errors.Errorf("Corrupted CompactCorpus. %q refers to ID %d, which is out of bounds", w, id)
+		}
+	}
+	c.rehash(indexSizeFor(len(c.offsets)))
+
+	// the prefix index isn't serialized
+	if c.trie != nil {
+		c.BuildTrie()
+	}
+	return nil
+}
+
+// word returns the bytes of the word with the given ID. The returned slice must not be modified.
+func (c *CompactCorpus) word(id int) []byte {
+	var start uint32
+	if id > 0 {
+		start = c.offsets[id-1]
+	}
+	return c.arena[start:c.offsets[id]]
+}
+
+// find returns the slot in the index where the word is, or the empty slot where it would be inserted.
+// It returns -1 if there is no index yet, as in a zero value CompactCorpus.
+func (c *CompactCorpus) find(word string) int {
+	if len(c.index) == 0 {
+		return -1
+	}
+	mask := len(c.index) - 1
+	slot := int(fnv32(word)) & mask
+	for {
+		entry := c.index[slot]
+		if entry == 0 || string(c.word(int(entry-1))) == word {
+			return slot
+		}
+		slot = (slot + 1) & mask
+	}
+}
+
+// rehash rebuilds the index with the given size, which must be a power of 2.
+func (c *CompactCorpus) rehash(size int) {
+	c.index = make([]uint32, size)
+	mask := size - 1
+	for id := range c.offsets {
+		slot := int(fnv32Bytes(c.word(id))) & mask
+		for c.index[slot] != 0 {
+			slot = (slot + 1) & mask
+		}
+		c.index[slot] = uint32(id + 1)
+	}
+}
+
+func (c *CompactCorpus) setFreq(id, freq int) {
+	if freq >= math.MaxUint32 || freq < 0 {
+		c.freqs[id] = math.MaxUint32
+		if c.big == nil {
+			c.big = make(map[int32]int)
+		}
+		c.big[int32(id)] = freq
+		return
+	}
+	c.freqs[id] = uint32(freq)
+	delete(c.big, int32(id))
+}
+
+// indexSizeFor returns the smallest power of 2 index size that holds n entries under the maximum load factor.
+func indexSizeFor(n int) int {
+	size := minCompactIndexSize
+	for n*4 > size*3 {
+		size *= 2
+	}
+	return size
+}
+
+const (
+	fnv32Offset = 2166136261
+	fnv32Prime  = 16777619
+)
+
+// fnv32 is the 32 bit FNV-1a hash of a string. It's written out by hand because hash/fnv would require a []byte.
+func fnv32(s string) uint32 {
+	h := uint32(fnv32Offset)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= fnv32Prime
+	}
+	return h
+}
+
+func fnv32Bytes(s []byte) uint32 {
+	h := uint32(fnv32Offset)
+	for _, b := range s {
+		h ^= uint32(b)
+		h *= fnv32Prime
+	}
+	return h
+}
@@ -0,0 +1,287 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactCorpus(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCompact()
+	assert.Equal(0, c.WordFreq("hello"))
+	assert.Equal(0, c.IDFreq(3))
+
+	id := c.Add("hello")
+	assert.Equal(3, id)
+	assert.Equal(4, c.Size())
+
+	id2, ok := c.Id("hello")
+	assert.True(ok)
+	assert.Equal(id, id2)
+
+	word, ok := c.Word(3)
+	assert.True(ok)
+	assert.Equal("hello", word)
+	_, ok = c.Word(4)
+	assert.False(ok)
+
+	c.Add(word)
+	assert.Equal(2, c.WordFreq(word))
+	assert.Equal(5, c.TotalFreq())
+	assert.Equal(5, c.MaxWordLength())
+
+	prob, ok := c.WordProb(word)
+	assert.True(ok)
+	assert.Equal(0.4, prob)
+
+	// frequencies that don't fit in 32 bits
+	big := c.AddN("the", math.MaxUint32+10)
+	assert.Equal(math.MaxUint32+10, c.IDFreq(big))
+	c.AddN("the", 1)
+	assert.Equal(math.MaxUint32+11, c.IDFreq(big))
+
+	// force a few rehashes
+	for i := 0; i < 1000; i++ {
+		c.Add(fmt.Sprintf("word%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		id, ok := c.Id(fmt.Sprintf("word%d", i))
+		assert.True(ok)
+		assert.Equal(i+5, id)
+	}
+}
+
+func TestCompactCorpus_ZeroValue(t *testing.T) {
+	assert := assert.New(t)
+	var c CompactCorpus
+	_, ok := c.Id("hello")
+	assert.False(ok)
+	assert.Equal(0, c.WordFreq("hello"))
+	_, ok = c.WordProb("hello")
+	assert.False(ok)
+
+	assert.Equal(0, c.Add("hello"))
+	assert.Equal(1, c.AddN("big", math.MaxUint32+1))
+	id, ok := c.Id("hello")
+	assert.True(ok)
+	assert.Equal(0, id)
+	assert.Equal(math.MaxUint32+1, c.WordFreq("big"))
+}
+
+func TestCompact(t *testing.T) {
+	f, err := os.Open("testdata/corpus_en.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	c, err := FromTextCorpus(f, nil, strings.ToLower)
+	require.NoError(t, err)
+
+	cc := Compact(c)
+	assert.Equal(t, c.Size(), cc.Size())
+	assert.Equal(t, c.TotalFreq(), cc.TotalFreq())
+	assert.Equal(t, c.MaxWordLength(), cc.MaxWordLength())
+	for i, w := range c.words {
+		id, ok := cc.Id(w)
+		assert.True(t, ok)
+		assert.Equal(t, i, id)
+		assert.Equal(t, c.frequencies[i], cc.IDFreq(i))
+	}
+
+	assert.Equal(t, c, cc.Corpus())
+}
+
+func TestCompactCorpusGob(t *testing.T) {
+	c := NewCompact()
+	c.Add("Hello")
+	c.Add("World")
+	c.AddN("Big", math.MaxUint32+1)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, gob.NewEncoder(buf).Encode(c))
+
+	c2 := new(CompactCorpus)
+	require.NoError(t, gob.NewDecoder(buf).Decode(c2))
+	assert.Equal(t, c, c2)
+}
+
+// benchWords generates n distinct words of varying lengths.
+func benchWords(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%x%s", i*2654435761, strings.Repeat("x", i%7))
+	}
+	return words
+}
+
+func heapInUse() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func BenchmarkCorpus_Memory(b *testing.B) {
+	words := benchWords(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		before := heapInUse()
+		c := New()
+		for _, w := range words {
+			c.Add(w)
+		}
+		b.ReportMetric(float64(heapInUse()-before)/float64(len(words)), "B/word")
+		runtime.KeepAlive(c)
+	}
+}
+
+func BenchmarkCompactCorpus_Memory(b *testing.B) {
+	words := benchWords(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		before := heapInUse()
+		c := NewCompact()
+		for _, w := range words {
+			c.Add(w)
+		}
+		b.ReportMetric(float64(heapInUse()-before)/float64(len(words)), "B/word")
+		runtime.KeepAlive(c)
+	}
+}
+
+func BenchmarkCorpus_Id(b *testing.B) {
+	words := benchWords(1 << 16)
+	c := New()
+	for _, w := range words {
+		c.Add(w)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Id(words[i&(len(words)-1)])
+	}
+}
+
+func BenchmarkCompactCorpus_Id(b *testing.B) {
+	words := benchWords(1 << 16)
+	c := NewCompact()
+	for _, w := range words {
+		c.Add(w)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Id(words[i&(len(words)-1)])
+	}
+}
+
+// sameVocab checks that a CompactCorpus has the same words, IDs and frequencies as a Corpus.
+func sameVocab(t *testing.T, c *Corpus, cc *CompactCorpus) {
+	assert := assert.New(t)
+	assert.Equal(c.Size(), cc.Size())
+	assert.Equal(c.TotalFreq(), cc.TotalFreq())
+	assert.Equal(c.MaxWordLength(), cc.MaxWordLength())
+	for w, id := range c.ids {
+		id2, ok := cc.Id(w)
+		assert.True(ok, "%q is missing", w)
+		assert.Equal(id, id2, "ID of %q", w)
+		assert.Equal(c.IDFreq(id), cc.IDFreq(id))
+	}
+}
+
+func TestCompactCorpus_MergePrune(t *testing.T) {
+	assert := assert.New(t)
+	c, cc := New(), NewCompact()
+	other, otherCC := New(), NewCompact()
+	for _, w := range []string{"a", "bb", "bb", "ccc"} {
+		c.Add(w)
+		cc.Add(w)
+	}
+	for _, w := range []string{"bb", "dddd", "ccc", "eeeee"} {
+		other.Add(w)
+		otherCC.Add(w)
+	}
+	cc.AddN("big", math.MaxUint32+1)
+	c.AddN("big", math.MaxUint32+1)
+
+	c.Merge(other)
+	cc.Merge(otherCC)
+	sameVocab(t, c, cc)
+	assert.Equal(5, cc.MaxWordLength())
+
+	c.Prune(2, "-UNKNOWN-", "a")
+	cc.Prune(2, "-UNKNOWN-", "a")
+	sameVocab(t, c, cc)
+	_, ok := cc.Id("eeeee")
+	assert.False(ok)
+	assert.Equal(math.MaxUint32+1, cc.WordFreq("big"))
+	assert.Equal(3, cc.MaxWordLength())
+}
+
+func TestCompactCorpus_Replace(t *testing.T) {
+	assert := assert.New(t)
+	c, cc := New(), NewCompact()
+	for _, w := range []string{"foo", "bar", "baz"} {
+		c.Add(w)
+		cc.Add(w)
+	}
+	require.NoError(t, c.Replace("bar", "quux"))
+	require.NoError(t, cc.Replace("bar", "quux"))
+	require.NoError(t, c.ReplaceWord(3, "f"))
+	require.NoError(t, cc.ReplaceWord(3, "f"))
+	sameVocab(t, c, cc)
+
+	// the old words still refer to their IDs, and count towards them
+	id, ok := cc.Id("bar")
+	assert.True(ok)
+	assert.Equal(4, id)
+	w, _ := cc.Word(4)
+	assert.Equal("quux", w)
+	assert.Equal(5, cc.Add("baz"))
+	assert.Equal(4, cc.Add("bar"))
+	assert.Equal(2, cc.WordFreq("quux"))
+	assert.Equal(4, cc.MaxWordLength())
+
+	assert.Error(cc.Replace("nope", "x"))
+	assert.Error(cc.Replace("foo", "baz"))
+	assert.Error(cc.ReplaceWord(10, "x"))
+	assert.Error(cc.ReplaceWord(3, "bar"))
+
+	// aliases survive conversions and gob
+	buf := new(bytes.Buffer)
+	require.NoError(t, gob.NewEncoder(buf).Encode(cc))
+	cc2 := new(CompactCorpus)
+	require.NoError(t, gob.NewDecoder(buf).Decode(cc2))
+	assert.Equal(cc, cc2)
+	id, ok = cc.Corpus().Id("bar")
+	assert.True(ok)
+	assert.Equal(4, id)
+	id, ok = Compact(c).Id("foo")
+	assert.True(ok)
+	assert.Equal(3, id)
+}
+
+func TestCompactCorpus_Trie(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCompact()
+	c.Add("hello")
+	assert.Nil(c.Trie())
+
+	trie := c.BuildTrie()
+	assert.Equal(trie, c.Trie())
+	c.Add("help")
+	require.NoError(t, c.Replace("hello", "helm"))
+	assert.Equal([]string{"hello", "helm", "help"}, trie.WithPrefix("hel"))
+	id, ok := trie.Lookup("helm")
+	assert.True(ok)
+	assert.Equal(3, id)
+
+	// pruning rebuilds it
+	c.Prune(1)
+	assert.Equal([]string{"helm", "help"}, c.Trie().WithPrefix("hel"))
+}
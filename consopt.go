@@ -26,7 +26,7 @@ func WithWords(a []string) ConsOpt {
 		// NOTE: here we're iterating over the set of words
 		for i, w := range s {
 			runeCount := utf8.RuneCountInString(w)
-			if runeCount > c.maxWordLength {
+			if runeCount > maxWL {
 				maxWL = runeCount
 			}
 
@@ -64,7 +64,7 @@ func WithOrderedWords(a []string) ConsOpt {
 		var maxWL int
 		for i, w := range a {
 			runeCount := utf8.RuneCountInString(w)
-			if runeCount > c.maxWordLength {
+			if runeCount > maxWL {
 				maxWL = runeCount
 			}
 			ids[w] = i
@@ -88,6 +88,14 @@ func WithSize(size int) ConsOpt {
 	}
 }
 
+// WithTrie builds a prefix index over the words of the corpus once all the other options have been applied. See (*Corpus).BuildTrie.
+func WithTrie() ConsOpt {
+	return func(c *Corpus) error {
+		c.trie = new(Trie)
+		return nil
+	}
+}
+
 // FromDict is a construction option to take a map[string]int where the int represents the word ID.
 // This is useful for constructing corpuses from foreign sources where the ID mappings are important
 func FromDict(d map[string]int) ConsOpt {
@@ -114,7 +122,7 @@ func FromDict(d map[string]int) ConsOpt {
 			}
 		}
 		c.maxid = int64(len(a.words))
-		return nil
+		return c.Repair()
 	}
 
 }
@@ -145,6 +153,6 @@ func FromDictWithFreq(d map[string]struct{ ID, Freq int }) ConsOpt {
 			}
 		}
 		c.maxid = int64(len(a.words))
-		return nil
+		return c.Repair()
 	}
 }
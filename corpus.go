@@ -1,7 +1,6 @@
 package corpus
 
 import (
-	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
@@ -9,25 +8,20 @@ import (
 
 // Corpus is a data structure holding the relevant metadata and information for a corpus of text.
 // It serves as vocabulary with ID for lookup. This is very useful as neural networks rely on the IDs rather than the text themselves
+//
+// Corpus is a Vocab of strings. On top of what Vocab provides, it keeps track of the length of the longest word.
 type Corpus struct {
-	words       []string
-	frequencies []int
-
-	ids map[string]int
+	Vocab[string]
 
-	// atomic read and write plz
-	maxid         int64
-	totalFreq     int
 	maxWordLength int
+
+	trie       *Trie               // optional prefix index. See BuildTrie.
+	signatures map[string]struct{} // the entries of the classes of unknown words. See AddSignatures.
 }
 
 // New creates a new *Corpus
 func New() *Corpus {
-	c := &Corpus{
-		words:       make([]string, 0),
-		frequencies: make([]int, 0),
-		ids:         make(map[string]int),
-	}
+	c := &Corpus{Vocab: makeVocab[string](0)}
 
 	// add some default words
 	c.Add("") // aka NULL - when there are no words
@@ -59,78 +53,51 @@ func Construct(opts ...ConsOpt) (*Corpus, error) {
 		}
 	}
 
-	return c, nil
-}
-
-// ID returns the ID of a word and whether or not it was found in the corpus
-func (c *Corpus) Id(word string) (int, bool) {
-	id, ok := c.ids[word]
-	return id, ok
-}
-
-// Word returns the word given the ID, and whether or not it was found in the corpus
-func (c *Corpus) Word(id int) (string, bool) {
-	size := atomic.LoadInt64(&c.maxid)
-	maxid := int(size)
-
-	if id >= maxid {
-		return "", false
+	if c.trie != nil {
+		c.BuildTrie()
 	}
-	return c.words[id], true
+
+	return c, nil
 }
 
 // Add adds a word to the corpus and returns its ID. If a word was previously in the corpus, it merely updates the frequency count and returns the ID
 func (c *Corpus) Add(word string) int {
-	if id, ok := c.ids[word]; ok {
-		c.frequencies[id]++
-		c.totalFreq++
-		return id
+	id := c.Vocab.Add(word)
+	if c.trie != nil {
+		c.trie.Insert(word, id)
 	}
 
-	id := atomic.AddInt64(&c.maxid, 1)
-	c.ids[word] = int(id - 1)
-	c.words = append(c.words, word)
-	c.frequencies = append(c.frequencies, 1)
-	c.totalFreq++
-
 	runeCount := utf8.RuneCountInString(word)
 	if runeCount > c.maxWordLength {
 		c.maxWordLength = runeCount
 	}
 
-	return int(id - 1)
+	return id
 }
 
-// Size returns the size of the corpus.
-func (c *Corpus) Size() int {
-	size := atomic.LoadInt64(&c.maxid)
-	return int(size)
-}
+// AddN adds n occurrences of a word to the corpus and returns its ID.
+func (c *Corpus) AddN(word string, n int) int {
+	id := c.Vocab.AddN(word, n)
+	if c.trie != nil {
+		c.trie.Insert(word, id)
+	}
 
-// WordFreq returns the frequency of the word. If the word wasn't in the corpus, it returns 0.
-func (c *Corpus) WordFreq(word string) int {
-	id, ok := c.ids[word]
-	if !ok {
-		return 0
+	runeCount := utf8.RuneCountInString(word)
+	if runeCount > c.maxWordLength {
+		c.maxWordLength = runeCount
 	}
 
-	return c.frequencies[id]
+	return id
 }
 
-// IDFreq returns the frequency of a word given an ID. If the word isn't in the corpus it returns 0.
-func (c *Corpus) IDFreq(id int) int {
-	size := atomic.LoadInt64(&c.maxid)
-	maxid := int(size)
-
-	if id >= maxid {
-		return 0
+// IsSpecial reports whether the word is one of the special words added by New, or the entry of a class of unknown words added by AddSignatures
+// or AddSentenceSignatures. Special words don't count towards the maximum word length, and are left out when models are trained on the corpus.
+func (c *Corpus) IsSpecial(word string) bool {
+	if _, ok := specialWords[word]; ok {
+		return true
 	}
-	return c.frequencies[id]
-}
-
-// TotalFreq returns the total number of words ever seen by the corpus. This number includes the count of repeat words.
-func (c *Corpus) TotalFreq() int {
-	return c.totalFreq
+	_, ok := c.signatures[word]
+	return ok
 }
 
 // MaxWordLength returns the length of the longest known word in the corpus.
@@ -138,33 +105,51 @@ func (c *Corpus) MaxWordLength() int {
 	return c.maxWordLength
 }
 
-// WordProb returns the probability of a word appearing in the corpus.
-func (c *Corpus) WordProb(word string) (float64, bool) {
-	id, ok := c.Id(word)
-	if !ok {
-		return 0, false
-	}
-
-	count := c.frequencies[id]
-	return float64(count) / float64(c.totalFreq), true
-
-}
-
 // Merge combines two corpuses. The receiver is the one that is mutated.
+// Entries of classes of unknown words (see AddSignatures) are skipped if they are ordinary words of the receiver.
 func (c *Corpus) Merge(other *Corpus) {
 	for i, word := range other.words {
-		freq := other.frequencies[i]
-		if id, ok := c.ids[word]; ok {
-			c.frequencies[id] += freq
-			c.totalFreq += freq
-		} else {
-			id := c.Add(word)
-			c.frequencies[id] += freq - 1
-			c.totalFreq += freq - 1
+		if !other.IsSpecial(word) {
+			c.AddN(word, other.frequencies[i])
+			continue
+		}
+		if _, ok := other.signatures[word]; ok {
+			c.addSignature(word, other.frequencies[i])
+			continue
+		}
+		// like signature entries, the special words added by New don't count towards the maximum word length
+		maxWordLength := c.maxWordLength
+		c.AddN(word, other.frequencies[i])
+		c.maxWordLength = maxWordLength
+	}
+}
+
+// Prune removes all words whose frequency is less than minFreq, except for the words listed in keep.
+// The remaining words are assigned new, contiguous IDs. Note that the special words added by New are not kept unless listed.
+func (c *Corpus) Prune(minFreq int, keep ...string) {
+	c.Vocab.Prune(minFreq, keep...)
+	for w := range c.signatures {
+		if _, ok := c.ids[w]; !ok {
+			delete(c.signatures, w)
 		}
 	}
+	c.maxWordLength, _ = c.longestWords()
+
+	if c.trie != nil {
+		c.BuildTrie()
+	}
+}
+
+// BuildTrie builds a prefix index over the words of the corpus and returns it. From then on, the index is kept in sync as words are added.
+// Calling BuildTrie again rebuilds the index from scratch. This is useful after manually mutating the corpus.
+func (c *Corpus) BuildTrie() *Trie {
+	c.trie = NewTrie(c)
+	return c.trie
 }
 
+// Trie returns the prefix index of the corpus, or nil if BuildTrie has never been called.
+func (c *Corpus) Trie() *Trie { return c.trie }
+
 // Replace replaces the content of a word. The old reference remains.
 //
 // e.g: c.Replace("foo", "bar")
@@ -179,6 +164,12 @@ func (c *Corpus) Replace(a, with string) error {
 	}
 	c.words[old] = with
 	c.ids[with] = old
+	if runeCount := utf8.RuneCountInString(with); runeCount > c.maxWordLength {
+		c.maxWordLength = runeCount
+	}
+	if c.trie != nil {
+		c.trie.Insert(with, old)
+	}
 	return nil
 
 }
@@ -193,5 +184,11 @@ func (c *Corpus) ReplaceWord(id int, with string) error {
 	}
 	c.words[id] = with
 	c.ids[with] = id
+	if runeCount := utf8.RuneCountInString(with); runeCount > c.maxWordLength {
+		c.maxWordLength = runeCount
+	}
+	if c.trie != nil {
+		c.trie.Insert(with, id)
+	}
 	return nil
 }
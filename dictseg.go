@@ -0,0 +1,174 @@
+package corpus
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// MatchMethod is the method a dictionary tokenizer uses to split runs of text written in scripts that don't use spaces.
+type MatchMethod int
+
+const (
+	// ForwardMaxMatch greedily takes the longest dictionary word from the start of the run.
+	ForwardMaxMatch MatchMethod = iota
+	// BackwardMaxMatch greedily takes the longest dictionary word from the end of the run.
+	BackwardMaxMatch
+	// ViterbiMatch takes the most probable split of the run, given the unigram probabilities of the dictionary. See ViterbiSegment.
+	ViterbiMatch
+)
+
+// unspacedScripts are the scripts that are written without spaces between words, and so need a dictionary to be split.
+var unspacedScripts = []*unicode.RangeTable{
+	unicode.Han,
+	unicode.Hiragana,
+	unicode.Katakana,
+	unicode.Thai,
+	unicode.Lao,
+	unicode.Khmer,
+	unicode.Myanmar,
+}
+
+// isUnspaced reports whether the rune belongs to a script written without spaces.
+// The prolonged sound mark and the iteration marks are shared by Hiragana and Katakana, but belong to the Common script.
+func isUnspaced(r rune) bool {
+	switch r {
+	case 'ー', '々', '〆', 'ゝ', 'ゞ', 'ヽ', 'ヾ':
+		return true
+	}
+	return unicode.In(r, unspacedScripts...)
+}
+
+type runKind int
+
+const (
+	spaceRun    runKind = iota // whitespace, which is dropped
+	unspacedRun                // CJK, Thai and the like, which are split with the dictionary
+	wordRun                    // letters, digits and marks of other scripts, which are kept intact
+	punctRun                   // everything else, each rune of which is a token of its own
+)
+
+func kindOf(r rune) runKind {
+	switch {
+	case unicode.IsSpace(r):
+		return spaceRun
+	case isUnspaced(r):
+		return unspacedRun
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_':
+		return wordRun
+	}
+	return punctRun
+}
+
+// DictTokenizer returns a tokenizer that splits text written in scripts without spaces (Chinese, Japanese, Thai, Lao, Khmer and Burmese)
+// into the words of the given dictionary, using the given method. Spans of other scripts and digits are kept intact, punctuation
+// becomes tokens of its own and whitespace is dropped. The options are passed on to ViterbiSegment when the method is ViterbiMatch.
+//
+// The returned function may be passed to FromTextCorpus as its tokenizer.
+func DictTokenizer(dict Vocabulary, method MatchMethod, opts ...SegmentOpt) func(a string) []string {
+	var trie *Trie
+	if t, ok := dict.(interface{ Trie() *Trie }); ok {
+		trie = t.Trie()
+	}
+	s := newSegmenter(dict, append([]SegmentOpt{PreserveCase()}, opts...)...)
+
+	return func(a string) []string {
+		retVal := make([]string, 0)
+		for start := 0; start < len(a); {
+			r, size := utf8.DecodeRuneInString(a[start:])
+			kind := kindOf(r)
+			end := start + size
+			if kind != punctRun {
+				for end < len(a) {
+					r, size := utf8.DecodeRuneInString(a[end:])
+					if kindOf(r) != kind {
+						break
+					}
+					end += size
+				}
+			}
+
+			run := a[start:end]
+			switch kind {
+			case unspacedRun:
+				switch method {
+				case ForwardMaxMatch:
+					retVal = append(retVal, forwardMaxMatch(run, dict, trie)...)
+				case BackwardMaxMatch:
+					retVal = append(retVal, backwardMaxMatch(run, dict)...)
+				default:
+					retVal = append(retVal, s.segment(run).Words...)
+				}
+			case wordRun, punctRun:
+				retVal = append(retVal, run)
+			}
+			start = end
+		}
+		return retVal
+	}
+}
+
+// forwardMaxMatch splits the text by repeatedly taking the longest dictionary word at its start. Runes that don't start any word become words of their own.
+func forwardMaxMatch(text string, dict Vocabulary, trie *Trie) []string {
+	maxLen := dict.MaxWordLength()
+	var retVal []string
+	ends := make([]int, 0, maxLen) // the byte offsets of the ends of the next maxLen runes
+	for len(text) > 0 {
+		var end int
+		if trie != nil {
+			w, _, ok := trie.LongestPrefix(text)
+			if ok {
+				end = len(w)
+			}
+		} else {
+			ends = ends[:0]
+			for pos := 0; pos < len(text) && len(ends) < maxLen; {
+				_, size := utf8.DecodeRuneInString(text[pos:])
+				pos += size
+				ends = append(ends, pos)
+			}
+			for n := len(ends); n > 1; n-- {
+				if _, ok := dict.Id(text[:ends[n-1]]); ok {
+					end = ends[n-1]
+					break
+				}
+			}
+		}
+		if end == 0 {
+			_, end = utf8.DecodeRuneInString(text)
+		}
+		retVal = append(retVal, text[:end])
+		text = text[end:]
+	}
+	return retVal
+}
+
+// backwardMaxMatch splits the text by repeatedly taking the longest dictionary word at its end. Runes that don't end any word become words of their own.
+func backwardMaxMatch(text string, dict Vocabulary) []string {
+	maxLen := dict.MaxWordLength()
+	var retVal []string
+	starts := make([]int, 0, maxLen) // the byte offsets of the starts of the last maxLen runes
+	for len(text) > 0 {
+		starts = starts[:0]
+		for pos := len(text); pos > 0 && len(starts) < maxLen; {
+			_, size := utf8.DecodeLastRuneInString(text[:pos])
+			pos -= size
+			starts = append(starts, pos)
+		}
+		_, size := utf8.DecodeLastRuneInString(text)
+		start := len(text) - size
+		for n := len(starts); n > 1; n-- {
+			if _, ok := dict.Id(text[starts[n-1]:]); ok {
+				start = starts[n-1]
+				break
+			}
+		}
+		retVal = append(retVal, text[start:])
+		text = text[:start]
+	}
+
+	// reverse it
+	for i, j := 0, len(retVal)-1; i < j; i, j = i+1, j-1 {
+		retVal[i], retVal[j] = retVal[j], retVal[i]
+	}
+	return retVal
+}
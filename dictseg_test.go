@@ -0,0 +1,74 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDictTokenizer(t *testing.T) {
+	assert := assert.New(t)
+	dict, err := Construct(WithWords([]string{"研究", "研究生", "生命", "生命", "命", "的", "起源", "我", "在", "工作", "了", "年", "สวัสดี", "ครับ", "食べる", "ラーメン"}))
+	require.NoError(t, err)
+
+	fmm := DictTokenizer(dict, ForwardMaxMatch)
+	bmm := DictTokenizer(dict, BackwardMaxMatch)
+	vit := DictTokenizer(dict, ViterbiMatch)
+
+	assert.Equal([]string{"研究生", "命", "的", "起源"}, fmm("研究生命的起源"))
+	assert.Equal([]string{"研究", "生命", "的", "起源"}, bmm("研究生命的起源"))
+	assert.Equal([]string{"研究", "生命", "的", "起源"}, vit("研究生命的起源"))
+
+	// Latin and digit spans are left intact, punctuation is split off
+	expected := []string{"我", "在", "Google", "工作", "了", "3", "年", "。"}
+	assert.Equal(expected, fmm("我在Google工作了3年。"))
+	assert.Equal(expected, bmm("我在Google工作了3年。"))
+	assert.Equal(expected, vit("我在Google工作了3年。"))
+
+	assert.Equal([]string{"สวัสดี", "ครับ"}, fmm("สวัสดีครับ"))
+	assert.Equal([]string{"ラーメン", "を", "食べる"}, bmm("ラーメンを食べる"))
+	assert.Equal([]string{"hello", ",", "world", "!"}, vit("hello, world!"))
+
+	dict.BuildTrie()
+	assert.Equal([]string{"研究生", "命", "的", "起源"}, DictTokenizer(dict, ForwardMaxMatch)("研究生命的起源"))
+}
+
+func TestDictTokenizer_FromTextCorpus(t *testing.T) {
+	dict, err := Construct(WithWords([]string{"研究", "生命", "的", "起源"}))
+	require.NoError(t, err)
+
+	c, err := FromTextCorpus(strings.NewReader("研究生命的起源\n生命的研究\n"), DictTokenizer(dict, ViterbiMatch), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, c.Size())
+	assert.Equal(t, 2, c.WordFreq("生命"))
+	assert.Equal(t, 2, c.WordFreq("研究"))
+}
+
+func TestDictTokenizer_LongRun(t *testing.T) {
+	assert := assert.New(t)
+	dict, err := Construct(WithWords([]string{"研究", "生命", "的", "起源"}))
+	require.NoError(t, err)
+
+	// a single run of 56000 runes, which must be split in linear time
+	text := strings.Repeat("研究生命的起源", 8000)
+	for _, method := range []MatchMethod{ForwardMaxMatch, BackwardMaxMatch} {
+		words := DictTokenizer(dict, method)(text)
+		assert.Len(words, 32000)
+		assert.Equal(text, strings.Join(words, ""))
+	}
+}
+
+func BenchmarkDictTokenizer_ForwardMaxMatch(b *testing.B) {
+	dict, err := Construct(WithWords([]string{"研究", "生命", "的", "起源"}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	fmm := DictTokenizer(dict, ForwardMaxMatch)
+	text := strings.Repeat("研究生命的起源", 2000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fmm(text)
+	}
+}
@@ -2,63 +2,200 @@ package corpus
 
 import (
 	"math"
+	"runtime"
 	"strings"
+	"sync"
+	"unicode"
 	"unicode/utf8"
 )
 
-// ViterbiSplit is a Viterbi algorithm for splitting words given a corpus
-func ViterbiSplit(input string, c *Corpus) []string {
-	s := strings.ToLower(input)
-	probabilities := []float64{1.0}
-	lasts := []int{0}
+// Segmentation is a split of an input string into words, along with the log probability of the split.
+type Segmentation struct {
+	Words []string
+	Score float64 // natural log probability of the segmentation
+}
+
+// SegmentOpt is an option for ViterbiSegment.
+type SegmentOpt func(s *segmenter)
+
+// PreserveCase makes ViterbiSegment return the words with the same case as the input.
+// Lookups try the word as it is first, falling back to the lowercased word.
+// Without PreserveCase, the input is lowercased, as ViterbiSplit has always done.
+func PreserveCase() SegmentOpt {
+	return func(s *segmenter) { s.preserveCase = true }
+}
+
+// WithPrefixIndex makes ViterbiSegment use the given Trie to find the words in the input.
+// The Trie must hold the words of the Vocabulary being segmented with.
+//
+// If the Vocabulary has a Trie method (as *Corpus does), its trie is used automatically.
+func WithPrefixIndex(t *Trie) SegmentOpt {
+	return func(s *segmenter) { s.trie = t }
+}
+
+// segmenter holds the configuration of a ViterbiSegment call.
+type segmenter struct {
+	v            Vocabulary
+	preserveCase bool
+	trie         *Trie
+	bigrams      *Bigrams
+	unknown      UnknownModel
 
-	runes := []int{}
-	for i := range s {
-		runes = append(runes, i)
+	maxLen int // the longest word considered, in runes
+}
+
+func newSegmenter(v Vocabulary, opts ...SegmentOpt) *segmenter {
+	s := &segmenter{v: v}
+	if t, ok := v.(interface{ Trie() *Trie }); ok {
+		s.trie = t.Trie()
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
-	runes = append(runes, len(s)+1)
 
-	for i := range s {
-		probs := make([]float64, 0)
-		ls := make([]int, 0)
+	s.maxLen = v.MaxWordLength()
+	if s.maxLen < 1 {
+		s.maxLen = 1
+	}
+	return s
+}
 
-		// m := maxInt(0, i-c.maxWordLength)
+// ViterbiSplit is a Viterbi algorithm for splitting words given a vocabulary
+func ViterbiSplit(input string, c Vocabulary) []string {
+	return ViterbiSegment(input, c).Words
+}
 
-		for j, r := range runes {
-			if r > i {
-				break
-			}
+// ViterbiSegment finds the most probable split of the input into words, given the unigram probabilities of the vocabulary.
+// The search is done in log space over rune boundaries, so long and non-ASCII inputs are handled correctly.
+//
+// Words that are not in the vocabulary are scored with a penalty that grows with their length.
+// No word (known or unknown) longer than the vocabulary's MaxWordLength is considered.
+func ViterbiSegment(input string, v Vocabulary, opts ...SegmentOpt) Segmentation {
+	return newSegmenter(v, opts...).segment(input)
+}
+
+// ViterbiSegmentBatch segments each of the inputs with ViterbiSegment, concurrently.
+// The results are in the same order as the inputs. The Vocabulary must be safe for concurrent reads.
+func ViterbiSegmentBatch(inputs []string, v Vocabulary, opts ...SegmentOpt) []Segmentation {
+	s := newSegmenter(v, opts...)
+	retVal := make([]Segmentation, len(inputs))
 
-			p, ok := c.WordProb(s[r : i+1])
-			if !ok {
-				// http://stackoverflow.com/questions/195010/how-can-i-split-multiple-joined-words#comment48879458_481773
-				p = (math.Log(float64(1)/float64(c.totalFreq)) - float64(c.maxWordLength) - float64(1)) * float64(i-r) // note it should be i-r not j-i as per the SO post
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				retVal[i] = s.segment(inputs[i])
 			}
-			prob := probabilities[j] * p
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return retVal
+}
 
-			probs = append(probs, prob)
-			ls = append(ls, r)
+// lattice is an input prepared for segmentation. Words are addressed by rune positions.
+type lattice struct {
+	text, lower       string // the text as it will be output, and its lowercased form
+	offsets, loffsets []int  // the byte offset of each rune in text and lower, followed by the length of the string
+}
+
+func (l *lattice) n() int                     { return len(l.offsets) - 1 }
+func (l *lattice) word(start, end int) string { return l.text[l.offsets[start]:l.offsets[end]] }
+func (l *lattice) lowerWord(start, end int) string {
+	return l.lower[l.loffsets[start]:l.loffsets[end]]
+}
+
+// prepare lowercases the input rune by rune, so that the text and its lowercased form have the same number of runes.
+func (s *segmenter) prepare(input string) *lattice {
+	var lower strings.Builder
+	lower.Grow(len(input))
+	l := &lattice{text: input}
+	for i, r := range input {
+		l.offsets = append(l.offsets, i)
+		l.loffsets = append(l.loffsets, lower.Len())
+		lower.WriteRune(unicode.ToLower(r))
+	}
+	l.lower = lower.String()
+	l.offsets = append(l.offsets, len(l.text))
+	l.loffsets = append(l.loffsets, len(l.lower))
+	if !s.preserveCase {
+		l.text, l.offsets = l.lower, l.loffsets
+	}
+	return l
+}
+
+// arcs calls fn with every word that may start at the given position: its end position and its log probability.
+func (s *segmenter) arcs(l *lattice, start int, fn func(end int, lp float64)) {
+	end := start + s.maxLen
+	if end > l.n() {
+		end = l.n()
+	}
+
+	if s.trie == nil {
+		for i := start + 1; i <= end; i++ {
+			fn(i, s.logProb(l.word(start, i), l.lowerWord(start, i)))
 		}
+		return
+	}
 
-		maxProb := -math.SmallestNonzeroFloat64
-		maxK := -1 << 63
-		for j, p := range probs {
-			if p > maxProb {
-				maxProb = p
-				maxK = ls[j]
-			}
+	// walk down the trie alongside the input. Once the trie runs out, every longer word is unknown.
+	// When the case isn't preserved, the text is the lowercased text, so one walk suffices.
+	exact, lower := &s.trie.root, &s.trie.root
+	if !s.preserveCase {
+		exact = nil
+	}
+	for i := start + 1; i <= end; i++ {
+		if exact != nil {
+			r, _ := utf8.DecodeRuneInString(l.text[l.offsets[i-1]:])
+			exact = exact.child(r, false)
+		}
+		if lower != nil {
+			r, _ := utf8.DecodeRuneInString(l.lower[l.loffsets[i-1]:])
+			lower = lower.child(r, false)
 		}
-		probabilities = append(probabilities, maxProb)
-		lasts = append(lasts, maxK)
+		if (exact == nil || exact.id < 0) && (lower == nil || lower.id < 0) {
+			fn(i, s.unknownLogProb(l.lowerWord(start, i)))
+			continue
+		}
+		fn(i, s.logProb(l.word(start, i), l.lowerWord(start, i)))
 	}
+}
 
-	words := make([]string, 0)
-	i := utf8.RuneCountInString(s)
+func (s *segmenter) segment(input string) Segmentation {
+	if s.bigrams != nil {
+		return s.segmentBigram(input)
+	}
+
+	l := s.prepare(input)
+	n := l.n()
+
+	scores := make([]float64, n+1) // scores[i] is the log probability of the best segmentation of the first i runes
+	lasts := make([]int, n+1)      // lasts[i] is where the last word of the best segmentation of the first i runes starts
+	for i := 1; i <= n; i++ {
+		scores[i] = math.Inf(-1)
+	}
+	for j := 0; j < n; j++ {
+		s.arcs(l, j, func(i int, lp float64) {
+			if score := scores[j] + lp; score > scores[i] {
+				scores[i] = score
+				lasts[i] = j
+			}
+		})
+	}
 
-	for i > 0 {
-		start := lasts[i]
-		words = append(words, s[start:i])
-		i = start
+	words := make([]string, 0)
+	for i := n; i > 0; i = lasts[i] {
+		words = append(words, l.word(lasts[i], i))
 	}
 
 	// reverse it
@@ -66,5 +203,33 @@ func ViterbiSplit(input string, c *Corpus) []string {
 		words[i], words[j] = words[j], words[i]
 	}
 
-	return words
+	return Segmentation{Words: words, Score: scores[n]}
+}
+
+// logProb returns the log probability of a word. word is the word as it appears in the (possibly lowercased) input, and lower is its lowercased form.
+func (s *segmenter) logProb(word, lower string) float64 {
+	if p, ok := s.v.WordProb(word); ok && p > 0 {
+		return math.Log(p)
+	}
+	if s.preserveCase {
+		if p, ok := s.v.WordProb(lower); ok && p > 0 {
+			return math.Log(p)
+		}
+	}
+	return s.unknownLogProb(lower)
+}
+
+// unknownLogProb is the log probability of an unknown word. Unless there is an UnknownModel, it only depends on the number of runes in the word.
+//
+// http://stackoverflow.com/questions/195010/how-can-i-split-multiple-joined-words#comment48879458_481773
+func (s *segmenter) unknownLogProb(word string) float64 {
+	if s.unknown != nil {
+		return s.unknown.UnknownLogProb(word)
+	}
+	length := utf8.RuneCountInString(word)
+	total := s.v.TotalFreq()
+	if total < 1 {
+		total = 1
+	}
+	return (math.Log(1/float64(total)) - float64(s.v.MaxWordLength()) - 1) * float64(length)
 }
@@ -1,6 +1,7 @@
 package corpus
 
 import (
+	"math"
 	"os"
 	"strings"
 	"testing"
@@ -32,3 +33,101 @@ func TestViterbiSplit(t *testing.T) {
 	words = ViterbiSplit(s3, dict)
 	assert.Equal([]string{"the", "best", "way", "to", "explain", "it", "is", "to", "do", "it"}, words)
 }
+
+func TestViterbiSegment(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"café", "crème", "café", "au", "lait"}))
+	require.NoError(t, err)
+
+	seg := ViterbiSegment("caféaulaitcrème", c)
+	assert.Equal([]string{"café", "au", "lait", "crème"}, seg.Words)
+
+	// the score is the sum of the log probabilities of the words
+	expected := math.Log(2.0/5.0) + math.Log(1.0/5.0) + math.Log(1.0/5.0) + math.Log(1.0/5.0)
+	assert.True(floatEquals64(expected, seg.Score))
+
+	seg = ViterbiSegment("CaféAuLait", c)
+	assert.Equal([]string{"café", "au", "lait"}, seg.Words)
+	seg = ViterbiSegment("CaféAuLait", c, PreserveCase())
+	assert.Equal([]string{"Café", "Au", "Lait"}, seg.Words)
+	assert.True(floatEquals64(math.Log(2.0/5.0)+2*math.Log(1.0/5.0), seg.Score))
+
+	// long inputs should not underflow
+	seg = ViterbiSegment(strings.Repeat("caféaulait", 200), c)
+	assert.Len(seg.Words, 600)
+	assert.False(math.IsInf(seg.Score, 0))
+
+	seg = ViterbiSegment("", c)
+	assert.Empty(seg.Words)
+	assert.Equal(0.0, seg.Score)
+}
+
+func TestViterbiSegment_PrefixIndex(t *testing.T) {
+	f, err := os.Open("testdata/corpus_en.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	dict, err := FromTextCorpus(f, nil, strings.ToLower)
+	require.NoError(t, err)
+
+	inputs := []string{"whiterabbit", "thebestwaytoexplainitistodoit", "TheQueenOfHearts", "offwithherhead", "xyzzyalice"}
+	without := make([]Segmentation, len(inputs))
+	for i, in := range inputs {
+		without[i] = ViterbiSegment(in, dict)
+	}
+
+	dict.BuildTrie()
+	for i, in := range inputs {
+		assert.Equal(t, without[i], ViterbiSegment(in, dict), "%q", in)
+	}
+	assert.Equal(t, without, ViterbiSegmentBatch(inputs, dict))
+
+	seg := ViterbiSegment("TheQueenOfHearts", dict, PreserveCase())
+	assert.Equal(t, []string{"The", "Queen", "Of", "Hearts"}, seg.Words)
+}
+
+func benchmarkCorpus(b *testing.B) *Corpus {
+	f, err := os.Open("testdata/corpus_en.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	dict, err := FromTextCorpus(f, nil, strings.ToLower)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return dict
+}
+
+const benchmarkSentence = "alicewasbeginningtogetverytiredofsittingbyhersisteronthebankandofhavingnothingtodo"
+
+func BenchmarkViterbiSegment_Long(b *testing.B) {
+	dict := benchmarkCorpus(b)
+	input := strings.Repeat(benchmarkSentence, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ViterbiSegment(input, dict)
+	}
+}
+
+func BenchmarkViterbiSegment_LongTrie(b *testing.B) {
+	dict := benchmarkCorpus(b)
+	dict.BuildTrie()
+	input := strings.Repeat(benchmarkSentence, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ViterbiSegment(input, dict)
+	}
+}
+
+func BenchmarkViterbiSegmentBatch(b *testing.B) {
+	dict := benchmarkCorpus(b)
+	dict.BuildTrie()
+	inputs := make([]string, 256)
+	for i := range inputs {
+		inputs[i] = benchmarkSentence
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ViterbiSegmentBatch(inputs, dict)
+	}
+}
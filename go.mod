@@ -1,10 +1,27 @@
 module github.com/go-nlp/corpus
 
-go 1.15
+go 1.18
 
 require (
 	github.com/chewxy/lingo v0.0.0-20200918122423-491e816b48d4
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.7.0
 	github.com/xtgo/set v1.0.0
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/awalterschulze/gographviz v0.0.0-20190221210632-1e9ccb565bca // indirect
+	github.com/chewxy/hm v1.0.0 // indirect
+	github.com/chewxy/math32 v1.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gogo/protobuf v1.2.1 // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/google/flatbuffers v1.10.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gonum.org/v1/gonum v0.0.0-20190221132855-8ea67971a689 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+	gorgonia.org/tensor v0.9.0-beta // indirect
+	gorgonia.org/vecf32 v0.7.0 // indirect
+	gorgonia.org/vecf64 v0.7.0 // indirect
 )
@@ -0,0 +1,169 @@
+package corpus
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// HashingVocab is a Vocabulary that never runs out of IDs: words of the underlying vocabulary keep their IDs, and every other word is hashed
+// (with 32 bit FNV-1a) into one of a fixed number of buckets, whose IDs come after the regular IDs. This is the hashing trick, for features whose
+// values are open ended, where a growing map of IDs cannot be afforded. Id always finds an ID; use Known to tell the words of the
+// underlying vocabulary apart.
+//
+// Words may be counted with Count, which keeps the number of times each bucket is used and the words that fell into it, so that collisions can be inspected.
+// The counts are reflected in WordFreq, WordProb and TotalFreq. A HashingVocab is not safe for concurrent use.
+type HashingVocab struct {
+	base    Vocabulary
+	buckets int
+
+	occurrences []int            // the number of times each bucket was counted
+	members     []map[string]int // the words counted in each bucket, with their counts
+	counted     int
+}
+
+// HashStats are statistics on the use of the buckets of a HashingVocab during counting.
+type HashStats struct {
+	Buckets     int // the number of buckets
+	Used        int // the number of buckets that at least one word fell into
+	Words       int // the number of distinct words counted into buckets
+	Collisions  int // the number of words that fell into a bucket another word had fallen into before. It is Words - Used.
+	MaxLoad     int // the largest number of distinct words in one bucket
+	Occurrences int // the number of times words were counted into buckets
+}
+
+// NewHashingVocab creates a HashingVocab with the given number of buckets over the base vocabulary.
+func NewHashingVocab(base Vocabulary, buckets int) (*HashingVocab, error) {
+	if buckets < 1 {
+		return nil, errors.Errorf("Cannot create a hashing vocabulary with %d buckets", buckets)
+	}
+	return &HashingVocab{
+		base:        base,
+		buckets:     buckets,
+		occurrences: make([]int, buckets),
+		members:     make([]map[string]int, buckets),
+	}, nil
+}
+
+// Buckets returns the number of buckets.
+func (h *HashingVocab) Buckets() int { return h.buckets }
+
+// Bucket returns the bucket the word hashes into, whether or not it is in the base vocabulary.
+func (h *HashingVocab) Bucket(word string) int {
+	return int(fnv32(word) % uint32(h.buckets))
+}
+
+// Id returns the ID of the word in the base vocabulary if it is there, and the ID of its bucket otherwise. It is always found.
+func (h *HashingVocab) Id(word string) (int, bool) {
+	if id, ok := h.base.Id(word); ok {
+		return id, true
+	}
+	return h.base.Size() + h.Bucket(word), true
+}
+
+// Known reports whether the word is in the base vocabulary.
+func (h *HashingVocab) Known(word string) bool {
+	_, ok := h.base.Id(word)
+	return ok
+}
+
+// IsBucket reports whether the ID is the ID of a bucket.
+func (h *HashingVocab) IsBucket(id int) bool {
+	return id >= h.base.Size() && id < h.Size()
+}
+
+// Word returns the word given its ID in the base vocabulary. Buckets are not words, so their IDs are not found.
+func (h *HashingVocab) Word(id int) (string, bool) {
+	if id >= h.base.Size() {
+		return "", false
+	}
+	return h.base.Word(id)
+}
+
+// Count counts an occurrence of the word, and returns its ID. Words in the base vocabulary are not counted.
+func (h *HashingVocab) Count(word string) int {
+	id, _ := h.Id(word)
+	if !h.IsBucket(id) {
+		return id
+	}
+	b := id - h.base.Size()
+	h.occurrences[b]++
+	if h.members[b] == nil {
+		h.members[b] = make(map[string]int)
+	}
+	h.members[b][word]++
+	h.counted++
+	return id
+}
+
+// BucketWords returns the words counted into the bucket, most frequent first.
+func (h *HashingVocab) BucketWords(bucket int) []string {
+	if bucket < 0 || bucket >= h.buckets {
+		return nil
+	}
+	m := h.members[bucket]
+	retVal := make([]string, 0, len(m))
+	for w := range m {
+		retVal = append(retVal, w)
+	}
+	sort.Slice(retVal, func(i, j int) bool {
+		if m[retVal[i]] != m[retVal[j]] {
+			return m[retVal[i]] > m[retVal[j]]
+		}
+		return retVal[i] < retVal[j]
+	})
+	return retVal
+}
+
+// Stats returns statistics on the buckets used so far.
+func (h *HashingVocab) Stats() HashStats {
+	retVal := HashStats{Buckets: h.buckets, Occurrences: h.counted}
+	for _, m := range h.members {
+		if len(m) == 0 {
+			continue
+		}
+		retVal.Used++
+		retVal.Words += len(m)
+		if len(m) > retVal.MaxLoad {
+			retVal.MaxLoad = len(m)
+		}
+	}
+	retVal.Collisions = retVal.Words - retVal.Used
+	return retVal
+}
+
+// Reset forgets all the counts.
+func (h *HashingVocab) Reset() {
+	for b := range h.occurrences {
+		h.occurrences[b] = 0
+		h.members[b] = nil
+	}
+	h.counted = 0
+}
+
+// WordFreq returns the frequency of the word in the base vocabulary, or the number of times its bucket was counted.
+func (h *HashingVocab) WordFreq(word string) int {
+	id, _ := h.Id(word)
+	if !h.IsBucket(id) {
+		return h.base.WordFreq(word)
+	}
+	return h.occurrences[id-h.base.Size()]
+}
+
+// WordProb returns the frequency of the word (see WordFreq) over TotalFreq. Like Id, it always finds the word.
+func (h *HashingVocab) WordProb(word string) (float64, bool) {
+	total := h.TotalFreq()
+	if total == 0 {
+		return 0, true
+	}
+	return float64(h.WordFreq(word)) / float64(total), true
+}
+
+// Size returns the number of IDs: the size of the base vocabulary plus the number of buckets.
+func (h *HashingVocab) Size() int { return h.base.Size() + h.buckets }
+
+// TotalFreq returns the total frequency of the base vocabulary plus the number of words counted into buckets.
+func (h *HashingVocab) TotalFreq() int { return h.base.TotalFreq() + h.counted }
+
+// MaxWordLength returns the length of the longest word of the base vocabulary.
+func (h *HashingVocab) MaxWordLength() int { return h.base.MaxWordLength() }
@@ -0,0 +1,89 @@
+package corpus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashingVocab(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"the", "cat", "sat"}))
+	require.NoError(t, err)
+	h, err := NewHashingVocab(c, 8)
+	require.NoError(t, err)
+	assert.Equal(11, h.Size())
+	assert.Equal(8, h.Buckets())
+
+	id, ok := h.Id("cat")
+	assert.True(ok)
+	cat, _ := c.Id("cat")
+	assert.Equal(cat, id)
+	assert.True(h.Known("cat"))
+	assert.False(h.IsBucket(id))
+
+	// unknown words are hashed into buckets after the regular IDs
+	id, ok = h.Id("dog")
+	assert.True(ok)
+	assert.False(h.Known("dog"))
+	assert.True(h.IsBucket(id))
+	assert.Equal(3+int(fnv32("dog")%8), id)
+	assert.Equal(h.Bucket("dog"), id-3)
+	_, ok = h.Word(id)
+	assert.False(ok)
+	w, ok := h.Word(cat)
+	assert.True(ok)
+	assert.Equal("cat", w)
+}
+
+func TestHashingVocab_Count(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"the", "cat"}))
+	require.NoError(t, err)
+	h, err := NewHashingVocab(c, 4)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		h.Count(fmt.Sprintf("word%d", i))
+	}
+	h.Count("word0")
+	h.Count("the")
+
+	stats := h.Stats()
+	assert.Equal(4, stats.Buckets)
+	assert.Equal(20, stats.Words)
+	assert.Equal(21, stats.Occurrences)
+	assert.Equal(stats.Words-stats.Used, stats.Collisions)
+	assert.True(stats.Used <= 4 && stats.Used > 0)
+	assert.True(stats.MaxLoad >= 5)
+
+	// every counted word is reported in its bucket, most frequent first
+	b := h.Bucket("word0")
+	words := h.BucketWords(b)
+	assert.Equal("word0", words[0])
+	var total int
+	for bucket := 0; bucket < 4; bucket++ {
+		for _, w := range h.BucketWords(bucket) {
+			assert.Equal(bucket, h.Bucket(w))
+			total++
+		}
+	}
+	assert.Equal(20, total)
+	assert.Nil(h.BucketWords(4))
+
+	assert.Equal(len(words)+1, h.WordFreq("word0"))
+	assert.Equal(1, h.WordFreq("the"))
+	assert.Equal(c.TotalFreq()+21, h.TotalFreq())
+	p, ok := h.WordProb("word0")
+	assert.True(ok)
+	assert.True(floatEquals64(float64(len(words)+1)/float64(h.TotalFreq()), p))
+
+	h.Reset()
+	assert.Equal(HashStats{Buckets: 4}, h.Stats())
+	assert.Equal(0, h.WordFreq("word0"))
+
+	_, err = NewHashingVocab(c, 0)
+	assert.Error(err)
+}
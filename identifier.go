@@ -0,0 +1,154 @@
+package corpus
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IdentifierSplitter splits identifiers found in source code (parseHTTPResponseJSON, max_word_len, utf8Decode) into their subtokens.
+//
+// Identifiers are split at underscores and other non alphanumeric runes, at transitions between lower and upper case,
+// between letters and digits, and at the end of acronyms (HTTPResponse becomes HTTP and Response). Subtokens keep their case.
+//
+// If a dictionary is given, lowercase or capitalized subtokens that aren't in it (maxwordlen) are further split with ViterbiSegment,
+// as long as every resulting word is in the dictionary. The dictionary is expected to hold lowercased words.
+//
+// The splitter records which subtokens every identifier it has seen was split into. An IdentifierSplitter is not safe for concurrent use.
+type IdentifierSplitter struct {
+	dict    Vocabulary
+	mapping map[string][]string
+}
+
+// NewIdentifierSplitter creates a new *IdentifierSplitter. dict may be nil, in which case glued lowercase words are left alone.
+func NewIdentifierSplitter(dict Vocabulary) *IdentifierSplitter {
+	return &IdentifierSplitter{
+		dict:    dict,
+		mapping: make(map[string][]string),
+	}
+}
+
+// Split splits a single identifier into its subtokens. The returned slice is a copy of the recorded subtokens, and may be modified.
+func (s *IdentifierSplitter) Split(ident string) []string {
+	subtokens, ok := s.mapping[ident]
+	if !ok {
+		subtokens = make([]string, 0)
+		for _, part := range splitIdentifier(ident) {
+			subtokens = append(subtokens, s.unglue(part)...)
+		}
+		s.mapping[ident] = subtokens
+	}
+
+	retVal := make([]string, len(subtokens))
+	copy(retVal, subtokens)
+	return retVal
+}
+
+// Tokenize splits text into identifiers (runs of letters, digits and underscores), and splits each identifier into its subtokens.
+// Everything else is dropped. It may be passed to FromTextCorpus as its tokenizer.
+func (s *IdentifierSplitter) Tokenize(a string) []string {
+	retVal := make([]string, 0)
+	start := -1
+	for i, r := range a {
+		isIdent := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		switch {
+		case isIdent && start < 0:
+			start = i
+		case !isIdent && start >= 0:
+			retVal = append(retVal, s.Split(a[start:i])...)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		retVal = append(retVal, s.Split(a[start:])...)
+	}
+	return retVal
+}
+
+// Mapping returns the subtokens of every identifier the splitter has seen. The returned map must not be modified.
+func (s *IdentifierSplitter) Mapping() map[string][]string { return s.mapping }
+
+// unglue splits a lowercase (or capitalized) subtoken that isn't a known word with ViterbiSegment, if every resulting word is known.
+func (s *IdentifierSplitter) unglue(part string) []string {
+	if s.dict == nil || utf8.RuneCountInString(part) < 2 {
+		return []string{part}
+	}
+	if _, ok := s.dict.Id(strings.ToLower(part)); ok {
+		return []string{part}
+	}
+	for i, r := range part {
+		if !unicode.IsLower(r) && i > 0 {
+			return []string{part}
+		}
+	}
+
+	words := ViterbiSegment(part, s.dict, PreserveCase()).Words
+	for _, w := range words {
+		if _, ok := s.dict.Id(strings.ToLower(w)); !ok {
+			return []string{part}
+		}
+	}
+	return words
+}
+
+type identClass int
+
+const (
+	identOther identClass = iota
+	identLower
+	identUpper
+	identDigit
+)
+
+func classOf(r rune) identClass {
+	switch {
+	case unicode.IsUpper(r) || unicode.IsTitle(r):
+		return identUpper
+	case unicode.IsLetter(r) || unicode.IsMark(r):
+		// letters without case (such as CJK) are treated as lowercase, so they stick to what precedes them
+		return identLower
+	case unicode.IsDigit(r):
+		return identDigit
+	}
+	return identOther
+}
+
+// splitIdentifier splits an identifier at separators, case transitions, letter-digit transitions and the ends of acronyms.
+func splitIdentifier(ident string) []string {
+	runes := []rune(ident)
+	var retVal []string
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			retVal = append(retVal, string(runes[start:end]))
+		}
+		start = end
+	}
+
+	for i, r := range runes {
+		cur := classOf(r)
+		if cur == identOther {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+
+		prev := classOf(runes[i-1])
+		switch {
+		case prev == identLower && cur == identUpper:
+			// camelCase
+			flush(i)
+		case (prev == identDigit) != (cur == identDigit):
+			// utf8Decode
+			flush(i)
+		case prev == identUpper && cur == identUpper && i+1 < len(runes) && classOf(runes[i+1]) == identLower:
+			// the last capital of an acronym starts the next word: HTTPResponse
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return retVal
+}
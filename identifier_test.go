@@ -0,0 +1,70 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	cases := []struct {
+		ident    string
+		expected []string
+	}{
+		{"parseHTTPResponseJSON", []string{"parse", "HTTP", "Response", "JSON"}},
+		{"max_word_len", []string{"max", "word", "len"}},
+		{"__init__", []string{"init"}},
+		{"utf8Decode", []string{"utf", "8", "Decode"}},
+		{"IOError", []string{"IO", "Error"}},
+		{"XMLHttpRequest2", []string{"XML", "Http", "Request", "2"}},
+		{"MAX_INT64", []string{"MAX", "INT", "64"}},
+		{"getX", []string{"get", "X"}},
+		{"x", []string{"x"}},
+		{"ÜberClass", []string{"Über", "Class"}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, splitIdentifier(c.ident), "%q", c.ident)
+	}
+}
+
+func TestIdentifierSplitter(t *testing.T) {
+	assert := assert.New(t)
+	dict, err := Construct(WithWords([]string{"max", "word", "len", "length", "new", "corpus", "get"}))
+	require.NoError(t, err)
+
+	s := NewIdentifierSplitter(dict)
+	assert.Equal([]string{"max", "word", "len"}, s.Split("maxwordlen"))
+	assert.Equal([]string{"New", "corpus"}, s.Split("Newcorpus"))
+	assert.Equal([]string{"get", "Max", "word", "length"}, s.Split("getMaxwordlength"))
+	// glued words that can't be fully explained by the dictionary are left alone
+	assert.Equal([]string{"maxqzx"}, s.Split("maxqzx"))
+
+	tokens := s.Tokenize("func (c *Corpus) maxwordlen() int { return c.max_word_len }")
+	assert.Equal([]string{"func", "c", "Corpus", "max", "word", "len", "int", "return", "c", "max", "word", "len"}, tokens)
+
+	mapping := s.Mapping()
+	assert.Equal([]string{"max", "word", "len"}, mapping["max_word_len"])
+	assert.Equal([]string{"Corpus"}, mapping["Corpus"])
+
+	// modifying what Split returns doesn't change what is recorded
+	split := s.Split("max_word_len")
+	split[0] = "min"
+	_ = append(split[:1], "x")
+	assert.Equal([]string{"max", "word", "len"}, s.Split("max_word_len"))
+	assert.Equal([]string{"max", "word", "len"}, mapping["max_word_len"])
+
+	// without a dictionary, glued words are not split
+	assert.Equal([]string{"maxwordlen"}, NewIdentifierSplitter(nil).Split("maxwordlen"))
+}
+
+func TestIdentifierSplitter_FromTextCorpus(t *testing.T) {
+	s := NewIdentifierSplitter(nil)
+	// the normalizer is applied before the tokenizer, so lowercasing has to wait until the identifiers have been split
+	c, err := FromTextCorpus(strings.NewReader("parseHTTPResponse(resp)\nhttpResponse := parse_response()\n"), s.Tokenize, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.WordFreq("Response"))
+	assert.Equal(t, 1, c.WordFreq("response"))
+	assert.Equal(t, 2, c.WordFreq("parse"))
+}
@@ -0,0 +1,271 @@
+package corpus
+
+import (
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// InduceOpt is an option for Induce.
+type InduceOpt func(in *inducer)
+
+// WithMaxInducedLength sets the length (in runes) of the longest word Induce considers. The default is 8.
+func WithMaxInducedLength(n int) InduceOpt {
+	return func(in *inducer) { in.maxLen = n }
+}
+
+// WithMinCount sets how many times a substring must occur in the text to be considered a candidate word, and how many
+// (expected) occurrences a word needs to survive each round of EM. Single runes are always kept. The default is 2.
+func WithMinCount(n float64) InduceOpt {
+	return func(in *inducer) { in.minCount = n }
+}
+
+// WithIterations sets the number of EM iterations Induce runs. The default is 10.
+func WithIterations(n int) InduceOpt {
+	return func(in *inducer) { in.iterations = n }
+}
+
+// WithWordPenalty sets a penalty (in nats) that is subtracted from the log probability of every word during induction.
+// It acts like a prior on the number of words: positive penalties favour fewer, longer words, and negative ones favour more, shorter words.
+// The default is 0.
+func WithWordPenalty(p float64) InduceOpt {
+	return func(in *inducer) { in.penalty = p }
+}
+
+type inducer struct {
+	maxLen     int
+	minCount   float64
+	iterations int
+	penalty    float64
+
+	logProbs map[string]float64
+}
+
+// Induce learns a vocabulary from unsegmented text, such as text written in a script that doesn't use spaces.
+// Each line is taken to be a run of words with no separators between them.
+//
+// Induce starts with every frequent enough substring as a candidate word, and re-estimates the unigram probabilities of the
+// candidates with EM (the forward-backward algorithm over all the segmentations of each line), pruning rare candidates as it goes.
+// The frequencies of the returned Corpus are the expected counts of the words in the text, rounded. The Corpus may then be used
+// with ViterbiSegment to segment text.
+func Induce(lines []string, opts ...InduceOpt) (*Corpus, error) {
+	in := &inducer{
+		maxLen:     8,
+		minCount:   2,
+		iterations: 10,
+	}
+	for _, opt := range opts {
+		opt(in)
+	}
+	if in.maxLen < 1 {
+		return nil, errors.Errorf("Cannot induce a vocabulary with a maximum word length of %d", in.maxLen)
+	}
+
+	in.seed(lines)
+	for i := 0; i < in.iterations; i++ {
+		in.step(lines)
+	}
+
+	// the words are ordered by expected count, so that the most frequent words have the smallest IDs
+	counts := in.expectedCounts(lines)
+	words := make([]string, 0, len(counts))
+	for w, count := range counts {
+		if math.Round(count) >= 1 {
+			words = append(words, w)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+
+	c := New()
+	for _, w := range words {
+		c.AddN(w, int(math.Round(counts[w])))
+	}
+	return c, nil
+}
+
+// seed counts every substring of up to maxLen runes, and keeps the frequent ones (and all single runes) as the initial candidates.
+func (in *inducer) seed(lines []string) {
+	counts := make(map[string]float64)
+	for _, line := range lines {
+		offsets := runeOffsets(line)
+		for i := 0; i < len(offsets)-1; i++ {
+			for j := i + 1; j < len(offsets) && j-i <= in.maxLen; j++ {
+				counts[line[offsets[i]:offsets[j]]]++
+			}
+		}
+	}
+	in.logProbs = in.normalize(counts)
+}
+
+// step is one iteration of EM.
+func (in *inducer) step(lines []string) {
+	in.logProbs = in.normalize(in.expectedCounts(lines))
+}
+
+// expectedCounts is the E step: the expected number of times each candidate occurs in the text under the current model.
+func (in *inducer) expectedCounts(lines []string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, line := range lines {
+		forwardBackward(line, 1, in.maxLen, in.logProb, counts)
+	}
+	return counts
+}
+
+func (in *inducer) logProb(word string) (float64, bool) {
+	lp, ok := in.logProbs[word]
+	return lp - in.penalty, ok
+}
+
+// normalize is the M step: it prunes the rare candidates, and turns the counts of the rest into log probabilities.
+func (in *inducer) normalize(counts map[string]float64) map[string]float64 {
+	var total float64
+	for w, count := range counts {
+		if count < in.minCount && utf8.RuneCountInString(w) > 1 {
+			delete(counts, w)
+			continue
+		}
+		total += count
+	}
+
+	logProbs := make(map[string]float64, len(counts))
+	for w, count := range counts {
+		if count <= 0 {
+			// a single rune that EM has given up on. It's kept (with a tiny probability) so that every line can still be segmented.
+			count = 1e-6
+		}
+		logProbs[w] = math.Log(count / total)
+	}
+	return logProbs
+}
+
+// forwardBackward adds the expected count of every word in every segmentation of the text, times weight, to counts, and returns the log likelihood of the text.
+// Words are at most maxLen runes long, and their log probabilities are given by logProb. Words for which logProb returns false are not considered.
+func forwardBackward(text string, weight float64, maxLen int, logProb func(word string) (float64, bool), counts map[string]float64) float64 {
+	offsets := runeOffsets(text)
+	n := len(offsets) - 1
+	if n == 0 {
+		return 0
+	}
+	word := func(i, j int) string { return text[offsets[i]:offsets[j]] }
+
+	alpha := make([]float64, n+1) // alpha[i] is the log probability of the first i runes, summed over all their segmentations
+	beta := make([]float64, n+1)  // beta[i] is the log probability of the runes from i on, summed over all their segmentations
+	for i := 1; i <= n; i++ {
+		alpha[i] = math.Inf(-1)
+		for j := i - 1; j >= 0 && i-j <= maxLen; j-- {
+			if lp, ok := logProb(word(j, i)); ok {
+				alpha[i] = logAddExp(alpha[i], alpha[j]+lp)
+			}
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		beta[i] = math.Inf(-1)
+		for j := i + 1; j <= n && j-i <= maxLen; j++ {
+			if lp, ok := logProb(word(i, j)); ok {
+				beta[i] = logAddExp(beta[i], lp+beta[j])
+			}
+		}
+	}
+
+	z := alpha[n]
+	if math.IsInf(z, -1) {
+		return z
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j <= n && j-i <= maxLen; j++ {
+			w := word(i, j)
+			if lp, ok := logProb(w); ok {
+				counts[w] += weight * math.Exp(alpha[i]+lp+beta[j]-z)
+			}
+		}
+	}
+	return z
+}
+
+// logAddExp computes log(exp(a) + exp(b)) without underflowing.
+func logAddExp(a, b float64) float64 {
+	if math.IsInf(a, -1) {
+		return b
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
+
+// runeOffsets returns the byte offset of every rune in s, followed by len(s).
+func runeOffsets(s string) []int {
+	offsets := make([]int, 0, len(s)+1)
+	for i := range s {
+		offsets = append(offsets, i)
+	}
+	return append(offsets, len(s))
+}
+
+// BoundaryEval holds the precision, recall and F1 score of predicted word boundaries against gold standard word boundaries.
+type BoundaryEval struct {
+	Precision, Recall, F1 float64
+}
+
+// EvaluateBoundaries scores predicted segmentations against gold segmentations of the same texts. Only the boundaries between
+// words count; the beginning and end of each text are not boundaries. Boundaries are compared by rune position.
+// When there are no predicted boundaries, none of them is wrong, so the precision is 1; likewise, the recall is 1 when there are no gold boundaries.
+// Texts that are single words in both segmentations are therefore a perfect match.
+func EvaluateBoundaries(predicted, gold [][]string) (BoundaryEval, error) {
+	if len(predicted) != len(gold) {
+		return BoundaryEval{}, errors.Errorf("Expected as many predicted segmentations as gold segmentations. Got %d and %d", len(predicted), len(gold))
+	}
+
+	var truePositives, predictedCount, goldCount int
+	for i := range predicted {
+		p, g := boundaries(predicted[i]), boundaries(gold[i])
+		if p.length != g.length {
+			return BoundaryEval{}, errors.Errorf("Segmentation %d does not cover the same text as the gold segmentation. %d runes vs %d runes", i, p.length, g.length)
+		}
+		for b := range p.positions {
+			if _, ok := g.positions[b]; ok {
+				truePositives++
+			}
+		}
+		predictedCount += len(p.positions)
+		goldCount += len(g.positions)
+	}
+
+	retVal := BoundaryEval{Precision: 1, Recall: 1}
+	if predictedCount > 0 {
+		retVal.Precision = float64(truePositives) / float64(predictedCount)
+	}
+	if goldCount > 0 {
+		retVal.Recall = float64(truePositives) / float64(goldCount)
+	}
+	if retVal.Precision+retVal.Recall > 0 {
+		retVal.F1 = 2 * retVal.Precision * retVal.Recall / (retVal.Precision + retVal.Recall)
+	}
+	return retVal, nil
+}
+
+type boundarySet struct {
+	positions map[int]struct{}
+	length    int
+}
+
+func boundaries(words []string) boundarySet {
+	retVal := boundarySet{positions: make(map[int]struct{})}
+	for i, w := range words {
+		if i > 0 {
+			retVal.positions[retVal.length] = struct{}{}
+		}
+		retVal.length += utf8.RuneCountInString(w)
+	}
+	return retVal
+}
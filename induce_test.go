@@ -0,0 +1,85 @@
+package corpus
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syntheticText generates unsegmented lines made of words drawn from a small lexicon with a Zipfian distribution, along with the gold segmentations.
+func syntheticText(n int, seed int64) (lines []string, gold [][]string) {
+	lexicon := []string{"the", "cat", "sat", "on", "mat", "dog", "ran", "to", "a", "big", "red", "house", "and", "bird", "sang"}
+	r := rand.New(rand.NewSource(seed))
+	z := rand.NewZipf(r, 1.1, 2, uint64(len(lexicon)-1))
+	for i := 0; i < n; i++ {
+		words := make([]string, 3+r.Intn(5))
+		for j := range words {
+			words[j] = lexicon[z.Uint64()]
+		}
+		lines = append(lines, strings.Join(words, ""))
+		gold = append(gold, words)
+	}
+	return
+}
+
+func TestInduce(t *testing.T) {
+	lines, gold := syntheticText(500, 1337)
+	c, err := Induce(lines, WithMaxInducedLength(6), WithMinCount(3))
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+
+	predicted := make([][]string, len(lines))
+	for i, line := range lines {
+		predicted[i] = ViterbiSplit(line, c)
+	}
+	eval, err := EvaluateBoundaries(predicted, gold)
+	require.NoError(t, err)
+	assert.True(t, eval.F1 > 0.8, "F1 is %v", eval.F1)
+
+	_, err = Induce(lines, WithMaxInducedLength(0))
+	assert.Error(t, err)
+}
+
+func TestEvaluateBoundaries(t *testing.T) {
+	assert := assert.New(t)
+	gold := [][]string{{"the", "cat", "sat"}, {"a", "dog"}}
+	predicted := [][]string{{"the", "c", "at", "sat"}, {"adog"}}
+	eval, err := EvaluateBoundaries(predicted, gold)
+	require.NoError(t, err)
+
+	// predicted boundaries: 3, 4, 6 and none. gold boundaries: 3, 6 and 1.
+	assert.True(floatEquals64(2.0/3.0, eval.Precision))
+	assert.True(floatEquals64(2.0/3.0, eval.Recall))
+	assert.True(floatEquals64(2.0/3.0, eval.F1))
+
+	// without any boundaries, single words match perfectly
+	eval, err = EvaluateBoundaries([][]string{{"cat"}, {"dog"}}, [][]string{{"cat"}, {"dog"}})
+	require.NoError(t, err)
+	assert.Equal(BoundaryEval{Precision: 1, Recall: 1, F1: 1}, eval)
+	eval, err = EvaluateBoundaries([][]string{{"adog"}}, [][]string{{"a", "dog"}})
+	require.NoError(t, err)
+	assert.Equal(BoundaryEval{Precision: 1, Recall: 0, F1: 0}, eval)
+	eval, err = EvaluateBoundaries([][]string{{"a", "dog"}}, [][]string{{"adog"}})
+	require.NoError(t, err)
+	assert.Equal(BoundaryEval{Precision: 0, Recall: 1, F1: 0}, eval)
+
+	_, err = EvaluateBoundaries(predicted[:1], gold)
+	assert.Error(err)
+	_, err = EvaluateBoundaries([][]string{{"the", "cat"}}, gold[:1])
+	assert.Error(err)
+}
+
+func TestForwardBackward(t *testing.T) {
+	// "ab" is either "a b" or "ab". With every word equally likely, the one word segmentation is e times as likely.
+	counts := make(map[string]float64)
+	lp := func(w string) (float64, bool) { return -1, true }
+	z := forwardBackward("ab", 1, 2, lp, counts)
+	assert.True(t, floatEquals64(logAddExp(-2, -1), z))
+	assert.True(t, floatEquals64(math.Exp(-1-z), counts["ab"]))
+	assert.True(t, floatEquals64(math.Exp(-2-z), counts["a"]))
+	assert.True(t, floatEquals64(math.Exp(-2-z), counts["b"]))
+}
@@ -51,62 +51,43 @@ func (c *Corpus) GobEncode() ([]byte, error) {
 	var buf bytes.Buffer
 	encoder := gob.NewEncoder(&buf)
 
-	if err := encoder.Encode(c.words); err != nil {
+	if err := c.Vocab.encode(encoder); err != nil {
 		return nil, err
 	}
 
-	if err := encoder.Encode(c.ids); err != nil {
-		return nil, err
-	}
-
-	if err := encoder.Encode(c.frequencies); err != nil {
-		return nil, err
-	}
-
-	if err := encoder.Encode(c.maxid); err != nil {
-		return nil, err
-	}
-
-	if err := encoder.Encode(c.totalFreq); err != nil {
+	if err := encoder.Encode(c.maxWordLength); err != nil {
 		return nil, err
 	}
 
-	if err := encoder.Encode(c.maxWordLength); err != nil {
+	if err := encoder.Encode(c.signatures); err != nil {
 		return nil, err
 	}
 
 	return buf.Bytes(), nil
 }
 
-// GobDecode implements GobDecoder for *Corpus
+// GobDecode implements GobDecoder for *Corpus. The derived fields of the decoded corpus are repaired (see Repair), but the corpus isn't validated.
 func (c *Corpus) GobDecode(buf []byte) error {
 	b := bytes.NewBuffer(buf)
 	decoder := gob.NewDecoder(b)
 
-	if err := decoder.Decode(&c.words); err != nil {
-		return err
-	}
-
-	if err := decoder.Decode(&c.ids); err != nil {
+	if err := c.Vocab.decode(decoder); err != nil {
 		return err
 	}
 
-	if err := decoder.Decode(&c.frequencies); err != nil {
-		return err
-	}
-
-	if err := decoder.Decode(&c.maxid); err != nil {
+	if err := decoder.Decode(&c.maxWordLength); err != nil {
 		return err
 	}
 
-	if err := decoder.Decode(&c.totalFreq); err != nil {
-		return err
-	}
-
-	if err := decoder.Decode(&c.maxWordLength); err != nil {
+	// corpuses encoded before signatures were added end here
+	c.signatures = nil
+	if err := decoder.Decode(&c.signatures); err != nil && err != io.EOF {
 		return err
 	}
 
+	// Repair also rebuilds the prefix index, which isn't serialized. What it cannot repair, such as words listed twice, is left for Validate
+	// to report, so that whatever decoded before Repair was added still decodes.
+	c.Repair()
 	return nil
 }
 
@@ -118,7 +99,14 @@ func (c *Corpus) GobDecode(buf []byte) error {
 // 		a	9081174698
 // 		in	8469404971
 // 		for	5933321709
+//
+// The frequency of every listed word is set to its count, replacing the frequency it had. If a word is listed more than once, the last count wins.
 func (c *Corpus) LoadOneGram(r io.Reader) error {
+	return LoadOneGram(r, c)
+}
+
+// LoadOneGram loads a 1_gram.txt file into any Builder. See (*Corpus).LoadOneGram for the file format.
+func LoadOneGram(r io.Reader, b Builder) error {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -134,25 +122,18 @@ func (c *Corpus) LoadOneGram(r io.Reader) error {
 			return err
 		}
 
-		id := c.Add(word)
-		c.frequencies[id] = count
-		c.totalFreq--
-		c.totalFreq += count
-
-		wc := len([]rune(word))
-		if wc > c.maxWordLength {
-			c.maxWordLength = wc
-		}
+		b.AddN(word, count-b.WordFreq(word))
 	}
 	return nil
 }
 
 // FromTextCorpus is a utility function to take in a text file, and return a Corpus.
+//
+// Every line is normalized and then tokenized. If tokenizer is nil, the line is split into words with a *WordTokenizer with the default options.
+// Pass SplitOnSpaces to split lines at spaces instead.
 func FromTextCorpus(r io.Reader, tokenizer func(a string) []string, normalizer func(a string) string) (*Corpus, error) {
 	if tokenizer == nil {
-		tokenizer = func(a string) []string {
-			return strings.Split(strings.Trim(a, "\r\n "), " ")
-		}
+		tokenizer = NewWordTokenizer().Tokenize
 	}
 	if normalizer == nil {
 		normalizer = func(a string) string { return a }
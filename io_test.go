@@ -43,6 +43,25 @@ func TestCorpusGob(t *testing.T) {
 	}
 }
 
+func TestCorpusGob_Duplicates(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.Add("Hello")
+	c.Add("World")
+	c.words[4] = "Hello"
+
+	// a corpus with a word listed twice still decodes, and what can be repaired is repaired
+	buf := new(bytes.Buffer)
+	require.NoError(t, gob.NewEncoder(buf).Encode(c))
+	decoded := new(Corpus)
+	require.NoError(t, gob.NewDecoder(buf).Decode(decoded))
+	id, ok := decoded.Id("Hello")
+	assert.True(ok)
+	assert.Equal(3, id)
+	assert.Equal(5, decoded.MaxWordLength())
+	assert.Error(decoded.Validate())
+}
+
 func TestCorpusToDict(t *testing.T) {
 	assert := assert.New(t)
 	c, _ := Construct(WithWords([]string{"World", "Hello", "World"}))
@@ -87,6 +106,14 @@ func TestLoadOneGram(t *testing.T) {
 		t.Errorf("Expected \"for\" to be in corpus after loading one gram file")
 	}
 	assert.Equal(int(c.maxid-1), id)
+
+	// counts replace frequencies, and the last count of a word wins
+	c = New()
+	total := c.TotalFreq()
+	require.NoError(t, c.LoadOneGram(strings.NewReader("the\t5\n-UNKNOWN-\t7\nthe\t3")))
+	assert.Equal(3, c.WordFreq("the"))
+	assert.Equal(7, c.WordFreq("-UNKNOWN-"))
+	assert.Equal(total-1+7+3, c.TotalFreq())
 }
 
 func TestFromTextCorpus(t *testing.T) {
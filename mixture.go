@@ -0,0 +1,173 @@
+package corpus
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Mixture is a Vocabulary that linearly interpolates the unigram probabilities of several vocabularies, such as a general
+// English corpus and a corpus of product names:
+//
+//	P(word) = Σ λ_k P_k(word)
+//
+// It may be used anywhere a Vocabulary is accepted, including ViterbiSegment.
+//
+// IDs are assigned to the union of the words of the components: first the words of the first component, then the words of the
+// second component that aren't in the first, and so on. WordFreq and TotalFreq are the sums of the components' counts. MaxWordLength
+// is the largest of the components'. A Mixture does not see words added to its components after it was created.
+type Mixture struct {
+	components []Vocabulary
+	weights    []float64
+
+	union         Vocab[string]
+	maxWordLength int
+}
+
+// NewMixture creates a mixture of the given vocabularies with the given interpolation weights, which are normalized to sum to 1.
+// If weights is nil, the vocabularies are weighted equally.
+func NewMixture(components []Vocabulary, weights []float64) (*Mixture, error) {
+	if len(components) == 0 {
+		return nil, errors.New("Cannot create a mixture of no vocabularies")
+	}
+	if weights == nil {
+		weights = make([]float64, len(components))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(components) {
+		return nil, errors.Errorf("Expected %d weights. Got %d instead", len(components), len(weights))
+	}
+
+	m := &Mixture{
+		components: components,
+		union:      makeVocab[string](components[0].Size()),
+	}
+	if err := m.SetWeights(weights); err != nil {
+		return nil, err
+	}
+
+	for _, c := range components {
+		for id := 0; id < c.Size(); id++ {
+			w, _ := c.Word(id)
+			m.union.AddN(w, c.WordFreq(w))
+		}
+		if c.MaxWordLength() > m.maxWordLength {
+			m.maxWordLength = c.MaxWordLength()
+		}
+	}
+	return m, nil
+}
+
+// Weights returns a copy of the interpolation weights.
+func (m *Mixture) Weights() []float64 {
+	retVal := make([]float64, len(m.weights))
+	copy(retVal, m.weights)
+	return retVal
+}
+
+// SetWeights sets the interpolation weights, normalizing them to sum to 1.
+func (m *Mixture) SetWeights(weights []float64) error {
+	if len(weights) != len(m.components) {
+		return errors.Errorf("Expected %d weights. Got %d instead", len(m.components), len(weights))
+	}
+	var sum float64
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) {
+			return errors.Errorf("Weight %d is %v. Weights must not be negative", i, w)
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return errors.New("Weights must not all be 0")
+	}
+
+	m.weights = make([]float64, len(weights))
+	for i, w := range weights {
+		m.weights[i] = w / sum
+	}
+	return nil
+}
+
+// Fit tunes the interpolation weights to maximize the likelihood of held-out words, with EM. Words that none of the components know are ignored.
+// It returns the average log probability of the known held-out words under the final weights.
+func (m *Mixture) Fit(heldout []string, iterations int) float64 {
+	probs := make([][]float64, 0, len(heldout))
+	for _, w := range heldout {
+		ps := make([]float64, len(m.components))
+		var known bool
+		for k, c := range m.components {
+			if p, ok := c.WordProb(w); ok && p > 0 {
+				ps[k] = p
+				known = true
+			}
+		}
+		if known {
+			probs = append(probs, ps)
+		}
+	}
+	if len(probs) == 0 {
+		return math.Inf(-1)
+	}
+
+	responsibilities := make([]float64, len(m.components))
+	for i := 0; i < iterations; i++ {
+		for k := range responsibilities {
+			responsibilities[k] = 0
+		}
+		for _, ps := range probs {
+			var z float64
+			for k, p := range ps {
+				z += m.weights[k] * p
+			}
+			for k, p := range ps {
+				responsibilities[k] += m.weights[k] * p / z
+			}
+		}
+		for k := range m.weights {
+			m.weights[k] = responsibilities[k] / float64(len(probs))
+		}
+	}
+
+	var ll float64
+	for _, ps := range probs {
+		var z float64
+		for k, p := range ps {
+			z += m.weights[k] * p
+		}
+		ll += math.Log(z)
+	}
+	return ll / float64(len(probs))
+}
+
+// Id returns the ID of a word in the union of the components, and whether or not any component knows it.
+func (m *Mixture) Id(word string) (int, bool) { return m.union.Id(word) }
+
+// Word returns the word given its ID in the union of the components.
+func (m *Mixture) Word(id int) (string, bool) { return m.union.Word(id) }
+
+// WordFreq returns the sum of the frequencies of the word in the components.
+func (m *Mixture) WordFreq(word string) int { return m.union.WordFreq(word) }
+
+// WordProb returns the interpolated probability of the word, and whether or not any component knows it.
+func (m *Mixture) WordProb(word string) (float64, bool) {
+	var p float64
+	var known bool
+	for k, c := range m.components {
+		if pk, ok := c.WordProb(word); ok {
+			p += m.weights[k] * pk
+			known = true
+		}
+	}
+	return p, known
+}
+
+// Size returns the number of distinct words in the union of the components.
+func (m *Mixture) Size() int { return m.union.Size() }
+
+// TotalFreq returns the sum of the frequencies of all the words in the components.
+func (m *Mixture) TotalFreq() int { return m.union.TotalFreq() }
+
+// MaxWordLength returns the length of the longest word in any of the components.
+func (m *Mixture) MaxWordLength() int { return m.maxWordLength }
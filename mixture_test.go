@@ -0,0 +1,77 @@
+package corpus
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixture(t *testing.T) {
+	assert := assert.New(t)
+	general, err := Construct(WithWords([]string{"the", "the", "new", "phone", "is", "out"}))
+	require.NoError(t, err)
+	domain, err := Construct(WithWords([]string{"iphone", "iphone", "pro", "max", "new"}))
+	require.NoError(t, err)
+
+	m, err := NewMixture([]Vocabulary{general, domain}, []float64{3, 1})
+	require.NoError(t, err)
+	assert.Equal([]float64{0.75, 0.25}, m.Weights())
+	assert.Equal(8, m.Size())
+	assert.Equal(11, m.TotalFreq())
+	assert.Equal(6, m.MaxWordLength())
+	assert.Equal(2, m.WordFreq("new"))
+
+	id, ok := m.Id("iphone")
+	assert.True(ok)
+	w, ok := m.Word(id)
+	assert.True(ok)
+	assert.Equal("iphone", w)
+
+	p, ok := m.WordProb("new")
+	assert.True(ok)
+	assert.True(floatEquals64(0.75*1.0/6.0+0.25*1.0/5.0, p))
+	p, ok = m.WordProb("pro")
+	assert.True(ok)
+	assert.True(floatEquals64(0.25*1.0/5.0, p))
+	_, ok = m.WordProb("android")
+	assert.False(ok)
+
+	_, err = NewMixture(nil, nil)
+	assert.Error(err)
+	_, err = NewMixture([]Vocabulary{general}, []float64{1, 2})
+	assert.Error(err)
+	assert.Error(m.SetWeights([]float64{-1, 2}))
+	assert.Error(m.SetWeights([]float64{0, 0}))
+}
+
+func TestMixture_Fit(t *testing.T) {
+	f, err := os.Open("testdata/corpus_en.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	general, err := FromTextCorpus(f, nil, strings.ToLower)
+	require.NoError(t, err)
+	domain, err := Construct(WithWords([]string{"iphone", "iphone", "pro", "max", "galaxy", "pixel", "new"}))
+	require.NoError(t, err)
+
+	// the general corpus alone doesn't know about phones
+	assert.NotEqual(t, []string{"the", "new", "iphone", "pro", "max"}, ViterbiSplit("thenewiphonepromax", general))
+
+	m, err := NewMixture([]Vocabulary{general, domain}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"the", "new", "iphone", "pro", "max"}, ViterbiSplit("thenewiphonepromax", m))
+
+	// held-out text that is mostly about phones should shift the weight towards the domain corpus
+	assert.True(t, math.IsInf(m.Fit(nil, 10), -1), "no held-out data should give -Inf")
+	ll := m.Fit(strings.Fields("the new pixel pro and the galaxy max"), 20)
+	weights := m.Weights()
+	assert.True(t, weights[1] > 0.5, "weights: %v", weights)
+	assert.True(t, floatEquals64(1, weights[0]+weights[1]))
+
+	uniform, err := NewMixture([]Vocabulary{general, domain}, nil)
+	require.NoError(t, err)
+	assert.True(t, ll > uniform.Fit(strings.Fields("the new pixel pro and the galaxy max"), 0))
+}
@@ -0,0 +1,150 @@
+package corpus
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// ViterbiSegmentN returns up to k of the most probable splits of the input into words, best first.
+// It accepts the same options as ViterbiSegment, including WithBigrams. The segmentations returned are all distinct.
+func ViterbiSegmentN(input string, v Vocabulary, k int, opts ...SegmentOpt) []Segmentation {
+	if k < 1 {
+		return nil
+	}
+	return newSegmenter(v, opts...).segmentN(input, k)
+}
+
+// hypothesis is a partial segmentation of the input ending at some position i.
+// Its last word spans the runes [start, i), and it extends the rank-th best hypothesis ending at start.
+// With bigrams, that hypothesis is in the beam of those whose last word is prevLen runes long.
+type hypothesis struct {
+	score       float64
+	start, rank int
+	prevLen     int
+}
+
+func (s *segmenter) segmentN(input string, k int) []Segmentation {
+	if s.bigrams != nil {
+		return s.segmentBigramN(input, k)
+	}
+
+	l := s.prepare(input)
+	n := l.n()
+
+	// beams[i] holds the k best hypotheses for the first i runes, best first.
+	// Until position i is reached, it accumulates all the candidate hypotheses.
+	beams := make([][]hypothesis, n+1)
+	beams[0] = []hypothesis{{start: -1, rank: -1}}
+	for j := 0; j < n; j++ {
+		beams[j] = pruneBeam(beams[j], k)
+		s.arcs(l, j, func(i int, lp float64) {
+			for r, h := range beams[j] {
+				beams[i] = append(beams[i], hypothesis{score: h.score + lp, start: j, rank: r})
+			}
+		})
+	}
+	beams[n] = pruneBeam(beams[n], k)
+
+	retVal := make([]Segmentation, 0, len(beams[n]))
+	seen := make(map[string]struct{})
+	for _, h := range beams[n] {
+		words := make([]string, 0)
+		for i, cur := n, h; i > 0; {
+			words = append(words, l.word(cur.start, i))
+			i, cur = cur.start, beams[cur.start][cur.rank]
+		}
+
+		// reverse it
+		for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+			words[i], words[j] = words[j], words[i]
+		}
+
+		key := strings.Join(words, "\x00")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		retVal = append(retVal, Segmentation{Words: words, Score: h.score})
+	}
+	return retVal
+}
+
+// segmentBigramN is segmentN with a bigram model. As in segmentBigram, the state at each position is the length of the last word,
+// and every state keeps its own beam.
+func (s *segmenter) segmentBigramN(input string, k int) []Segmentation {
+	l := s.prepare(input)
+	n := l.n()
+
+	// beams[i][d] holds the k best hypotheses for the first i runes whose last word is d runes long, best first.
+	// Until position i is reached, it accumulates all the candidate hypotheses.
+	beams := make([][][]hypothesis, n+1)
+	for i := range beams {
+		beams[i] = make([][]hypothesis, s.maxLen+1)
+	}
+	beams[0][0] = []hypothesis{{start: -1, rank: -1}}
+	for j := 0; j < n; j++ {
+		for pd := range beams[j] {
+			beams[j][pd] = pruneBeam(beams[j][pd], k)
+		}
+		s.arcs(l, j, func(i int, lp float64) {
+			word := l.lowerWord(j, i)
+			for pd, beam := range beams[j] {
+				if len(beam) == 0 {
+					continue
+				}
+				blp := s.bigrams.logProb(l.lowerWord(j-pd, j), word, lp)
+				if math.IsInf(blp, -1) {
+					continue
+				}
+				for r, h := range beam {
+					beams[i][i-j] = append(beams[i][i-j], hypothesis{score: h.score + blp, start: j, rank: r, prevLen: pd})
+				}
+			}
+		})
+	}
+
+	var final []hypothesis
+	for _, beam := range beams[n] {
+		final = append(final, beam...)
+	}
+	if len(final) == 0 {
+		// no path is possible with the bigrams
+		u := *s
+		u.bigrams = nil
+		return u.segmentN(input, k)
+	}
+	final = pruneBeam(final, k)
+
+	retVal := make([]Segmentation, 0, len(final))
+	seen := make(map[string]struct{})
+	for _, h := range final {
+		words := make([]string, 0)
+		for i, cur := n, h; i > 0; {
+			words = append(words, l.word(cur.start, i))
+			i, cur = cur.start, beams[cur.start][cur.prevLen][cur.rank]
+		}
+
+		// reverse it
+		for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+			words[i], words[j] = words[j], words[i]
+		}
+
+		key := strings.Join(words, "\x00")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		retVal = append(retVal, Segmentation{Words: words, Score: h.score})
+	}
+	return retVal
+}
+
+// pruneBeam sorts the hypotheses, best first, and keeps the k best.
+func pruneBeam(hyps []hypothesis, k int) []hypothesis {
+	sort.SliceStable(hyps, func(a, b int) bool { return hyps[a].score > hyps[b].score })
+	if len(hyps) > k {
+		hyps = hyps[:k]
+	}
+	return hyps
+}
@@ -0,0 +1,80 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViterbiSegmentN(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"expert", "expert", "experts", "exchange", "exchange", "sex", "change", "change", "s"}))
+	require.NoError(t, err)
+
+	segs := ViterbiSegmentN("expertsexchange", c, 5)
+	require.NotEmpty(t, segs)
+	assert.True(len(segs) <= 5)
+
+	// the best of the N best is the Viterbi best
+	best := ViterbiSegment("expertsexchange", c)
+	assert.Equal(best.Words, segs[0].Words)
+	assert.True(floatEquals64(best.Score, segs[0].Score))
+
+	seen := make(map[string]bool)
+	for i, seg := range segs {
+		if i > 0 {
+			assert.True(segs[i-1].Score >= seg.Score, "segmentations should be sorted by score")
+		}
+		key := ""
+		for _, w := range seg.Words {
+			key += w + " "
+		}
+		assert.False(seen[key], "duplicate segmentation %v", seg.Words)
+		seen[key] = true
+	}
+	assert.True(seen["experts exchange "])
+	assert.True(seen["expert sex change "])
+
+	assert.Nil(ViterbiSegmentN("expertsexchange", c, 0))
+}
+
+func TestViterbiSegmentN_Bigrams(t *testing.T) {
+	assert := assert.New(t)
+	var words []string
+	for i := 0; i < 10; i++ {
+		words = append(words, "expert", "sex", "change")
+	}
+	words = append(words, "experts", "exchange", "stock")
+	c, err := Construct(WithWords(words))
+	require.NoError(t, err)
+	b := NewBigrams(c, 0.9)
+	for i := 0; i < 10; i++ {
+		b.AddSentence([]string{"experts", "exchange"})
+		b.AddSentence([]string{"stock", "exchange"})
+	}
+
+	// the best of the N best is the Viterbi best with the bigrams, not the unigrams
+	segs := ViterbiSegmentN("expertsexchange", c, 5, WithBigrams(b))
+	require.NotEmpty(t, segs)
+	assert.True(len(segs) <= 5)
+	best := ViterbiSegment("expertsexchange", c, WithBigrams(b))
+	assert.Equal([]string{"experts", "exchange"}, segs[0].Words)
+	assert.True(floatEquals64(best.Score, segs[0].Score))
+
+	seen := make(map[string]bool)
+	for i, seg := range segs {
+		if i > 0 {
+			assert.True(segs[i-1].Score >= seg.Score, "segmentations should be sorted by score")
+		}
+		key := strings.Join(seg.Words, " ")
+		assert.False(seen[key], "duplicate segmentation %v", seg.Words)
+		seen[key] = true
+	}
+	assert.True(seen["expert sex change"])
+
+	segs = ViterbiSegmentN("", c, 5, WithBigrams(b))
+	require.Len(t, segs, 1)
+	assert.Empty(segs[0].Words)
+}
@@ -0,0 +1,95 @@
+package corpus
+
+// CharNGramOpt is an option for NewCharNGrams.
+type CharNGramOpt func(g *CharNGrams)
+
+// WithNGramRange sets the lengths (in runes, counting the < and > boundary markers) of the shortest and longest character n-grams. The default is 3 to 6, as in fastText.
+// A max of 0 turns n-grams off.
+func WithNGramRange(min, max int) CharNGramOpt {
+	return func(g *CharNGrams) { g.minN, g.maxN = min, max }
+}
+
+// WithBuckets sets the number of hash buckets the n-grams are spread over. The default is 2000000, as in fastText.
+func WithBuckets(n int) CharNGramOpt {
+	return func(g *CharNGrams) { g.buckets = n }
+}
+
+// CharNGrams maps words to the IDs of their character n-grams, the way fastText does (Bojanowski et al., 2017), so that the embeddings of unknown
+// words can be composed from the embeddings of their n-grams.
+//
+// Every word is wrapped in < and >, and its n-grams are hashed with fastText's variant of 32 bit FNV-1a into a fixed number of buckets.
+// The ID of an n-gram is the size of the vocabulary plus its bucket, so that words and n-grams can share one embedding table of Size() rows.
+type CharNGrams struct {
+	vocab      Vocabulary
+	minN, maxN int
+	buckets    int
+}
+
+// NewCharNGrams creates a CharNGrams over the given vocabulary.
+func NewCharNGrams(vocab Vocabulary, opts ...CharNGramOpt) *CharNGrams {
+	g := &CharNGrams{
+		vocab:   vocab,
+		minN:    3,
+		maxN:    6,
+		buckets: 2000000,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Size returns the number of IDs: the size of the vocabulary plus the number of buckets.
+func (g *CharNGrams) Size() int { return g.vocab.Size() + g.buckets }
+
+// IDs returns the ID of the word, if it is in the vocabulary, followed by the IDs of its n-grams. The IDs of n-grams that collide are repeated.
+func (g *CharNGrams) IDs(word string) []int {
+	var retVal []int
+	if id, ok := g.vocab.Id(word); ok {
+		retVal = append(retVal, id)
+	}
+	if g.buckets <= 0 {
+		return retVal
+	}
+	offset := g.vocab.Size()
+	g.each(word, func(ngram string) {
+		retVal = append(retVal, offset+int(fastTextHash(ngram)%uint32(g.buckets)))
+	})
+	return retVal
+}
+
+// NGrams returns the n-grams of the word, in the order fastText produces them: by starting position, and then by length.
+func (g *CharNGrams) NGrams(word string) []string {
+	var retVal []string
+	g.each(word, func(ngram string) { retVal = append(retVal, ngram) })
+	return retVal
+}
+
+// each calls fn with every n-gram of the bounded word. N-grams made of just one of the boundary markers are left out.
+func (g *CharNGrams) each(word string, fn func(ngram string)) {
+	if g.maxN <= 0 {
+		return
+	}
+	w := "<" + word + ">"
+	offsets := runeOffsets(w)
+	last := len(offsets) - 1
+	for i := 0; i < last; i++ {
+		for n := 1; n <= g.maxN && i+n <= last; n++ {
+			if n < g.minN || (n == 1 && (i == 0 || i+n == last)) {
+				continue
+			}
+			fn(w[offsets[i]:offsets[i+n]])
+		}
+	}
+}
+
+// fastTextHash is fastText's hash function. It is 32 bit FNV-1a, except that bytes are sign extended before they are mixed in,
+// which changes the hash of anything that isn't ASCII.
+func fastTextHash(s string) uint32 {
+	h := uint32(fnv32Offset)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(int8(s[i]))
+		h *= fnv32Prime
+	}
+	return h
+}
@@ -0,0 +1,55 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastTextHash(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(uint32(2166136261), fastTextHash(""))
+	assert.Equal(uint32(0xe40c292c), fastTextHash("a"))
+	assert.Equal(fnv32("<wh"), fastTextHash("<wh"))
+	assert.Equal(uint32(1048167652), fastTextHash("<wh"))
+
+	// non-ASCII bytes are sign extended, so the hash differs from FNV-1a
+	assert.Equal(uint32(1023043777), fastTextHash("é"))
+	assert.NotEqual(fnv32("é"), fastTextHash("é"))
+}
+
+func TestCharNGrams(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithOrderedWords([]string{"where", "here", "é"}))
+	require.NoError(t, err)
+
+	g := NewCharNGrams(c, WithNGramRange(3, 3))
+	assert.Equal([]string{"<wh", "whe", "her", "ere", "re>"}, g.NGrams("where"))
+
+	ids := g.IDs("where")
+	assert.Len(ids, 6)
+	assert.Equal(0, ids[0])
+	assert.Equal(3+int(fastTextHash("<wh")%2000000), ids[1])
+	assert.Equal(3+420941, ids[2])
+	assert.Equal(2000003, g.Size())
+
+	// unknown words only have n-grams
+	ids = g.IDs("wherever")
+	assert.Len(ids, 8)
+	for _, id := range ids {
+		assert.True(id >= 3 && id < g.Size())
+	}
+
+	// n-grams are made of runes, and single boundary markers are not n-grams
+	g = NewCharNGrams(c, WithNGramRange(1, 2), WithBuckets(10))
+	assert.Equal([]string{"<é", "é", "é>"}, g.NGrams("é"))
+	assert.Equal([]int{2, 3 + int(fastTextHash("<é")%10), 3 + int(fastTextHash("é")%10), 3 + int(fastTextHash("é>")%10)}, g.IDs("é"))
+
+	g = NewCharNGrams(c, WithNGramRange(3, 6))
+	assert.Len(g.NGrams("where"), 5+4+3+2)
+
+	g = NewCharNGrams(c, WithNGramRange(0, 0))
+	assert.Equal([]int{1}, g.IDs("here"))
+	assert.Nil(g.IDs("there"))
+}
@@ -0,0 +1,43 @@
+package corpus
+
+import "math/rand"
+
+// Sampler samples segmentations of words into subword IDs, for subword regularization during training: each time a word is seen,
+// it may be segmented differently. A Sampler is seeded, so the sequence of segmentations it produces is the same for the same seed.
+//
+// A Sampler is not safe for concurrent use. Use a Sampler per goroutine, each with its own seed.
+type Sampler struct {
+	rng    *rand.Rand
+	sample func(word string, rng *rand.Rand) []int
+}
+
+// NewBPESampler creates a Sampler that segments words with BPE-dropout, skipping every merge with probability p. See (*BPE).EncodeDropout.
+func NewBPESampler(b *BPE, p float64, seed int64) *Sampler {
+	return &Sampler{
+		rng:    rand.New(rand.NewSource(seed)),
+		sample: func(word string, rng *rand.Rand) []int { return b.EncodeDropoutIDs(word, p, rng) },
+	}
+}
+
+// NewUnigramSampler creates a Sampler that draws segmentations from a unigram model, with its probabilities raised to the power alpha. See (*Unigram).Sample.
+func NewUnigramSampler(u *Unigram, alpha float64, seed int64) *Sampler {
+	return &Sampler{
+		rng:    rand.New(rand.NewSource(seed)),
+		sample: func(word string, rng *rand.Rand) []int { return u.SampleIDs(word, alpha, rng) },
+	}
+}
+
+// Sample returns the IDs of a sampled segmentation of the word.
+func (s *Sampler) Sample(word string) []int { return s.sample(word, s.rng) }
+
+// SampleAll samples a segmentation of every word, and returns the concatenation of their IDs.
+func (s *Sampler) SampleAll(words []string) []int {
+	retVal := make([]int, 0, len(words))
+	for _, w := range words {
+		retVal = append(retVal, s.sample(w, s.rng)...)
+	}
+	return retVal
+}
+
+// Seed resets the Sampler to the start of the sequence of segmentations for the given seed.
+func (s *Sampler) Seed(seed int64) { s.rng.Seed(seed) }
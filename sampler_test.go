@@ -0,0 +1,43 @@
+package corpus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler(t *testing.T) {
+	assert := assert.New(t)
+	b, err := TrainBPE(sennrich(), 1000, WithEndOfWord("</w>"))
+	require.NoError(t, err)
+	u, err := TrainUnigram(morphology(), 40, WithMaxPieceLength(6))
+	require.NoError(t, err)
+	words := []string{"newest", "widest", "lowest", "walking", "jumpers"}
+
+	for _, newSampler := range []func(seed int64) *Sampler{
+		func(seed int64) *Sampler { return NewBPESampler(b, 0.2, seed) },
+		func(seed int64) *Sampler { return NewUnigramSampler(u, 0.1, seed) },
+	} {
+		// the same seed gives the same segmentations
+		s1, s2 := newSampler(42), newSampler(42)
+		var first [][]int
+		for i := 0; i < 20; i++ {
+			ids := s1.SampleAll(words)
+			assert.Equal(ids, s2.SampleAll(words))
+			first = append(first, ids)
+		}
+
+		// but they vary from one call to the next
+		var varied bool
+		for _, ids := range first[1:] {
+			varied = varied || !reflect.DeepEqual(first[0], ids)
+		}
+		assert.True(varied)
+
+		// reseeding starts the sequence over
+		s1.Seed(42)
+		assert.Equal(first[0], s1.SampleAll(words))
+	}
+}
@@ -0,0 +1,225 @@
+package corpus
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Signature maps a rare or unknown word to the name of its class, such as "UNK-INITC-ing" or "Xxxxx". i is the position of the word in its sentence,
+// or -1 if it isn't known.
+//
+// Mapping unknown words to classes keeps some of what they tell a tagger or a parser (capitalization, digits, suffixes), which a single
+// -UNKNOWN- entry throws away.
+type Signature func(word string, i int) string
+
+// signatureEntry is the special entry of a class in a Corpus. Like -UNKNOWN-, it is wrapped in dashes.
+func signatureEntry(class string) string { return "-" + class + "-" }
+
+// berkeleySuffixes are the suffixes the Berkeley parser picks out of English words, in the order it tries them.
+var berkeleySuffixes = []string{"ed", "ing", "ion", "er", "est", "ly", "ity", "y", "al"}
+
+// BerkeleySignature returns the unknown word signature of the Berkeley parser for English (Petrov et al., 2006), which looks at
+// capitalization, digits, dashes and common suffixes. For example, "Blarging" at the start of a sentence is "UNK-INITC-ing" and "3-D" is "UNK-CAPS-NUM-DASH".
+//
+// If known is not nil, capitalized words at the start of sentences whose lowercase form is known are marked KNOWNLC.
+func BerkeleySignature(known Vocabulary) Signature {
+	return func(word string, i int) string {
+		var b strings.Builder
+		b.WriteString("UNK")
+
+		var numCaps int
+		var hasDigit, hasDash, hasLower bool
+		for _, r := range word {
+			switch {
+			case unicode.IsDigit(r):
+				hasDigit = true
+			case r == '-':
+				hasDash = true
+			case unicode.IsLetter(r):
+				switch {
+				case unicode.IsLower(r):
+					hasLower = true
+				case unicode.IsTitle(r):
+					hasLower = true
+					numCaps++
+				default:
+					numCaps++
+				}
+			}
+		}
+
+		first, _ := utf8.DecodeRuneInString(word)
+		lower := strings.ToLower(word)
+		switch {
+		case unicode.IsUpper(first) || unicode.IsTitle(first):
+			if i == 0 && numCaps == 1 {
+				b.WriteString("-INITC")
+				if known != nil {
+					if _, ok := known.Id(lower); ok {
+						b.WriteString("-KNOWNLC")
+					}
+				}
+			} else {
+				b.WriteString("-CAPS")
+			}
+		case !unicode.IsLetter(first) && numCaps > 0:
+			b.WriteString("-CAPS")
+		case hasLower:
+			b.WriteString("-LC")
+		}
+		if hasDigit {
+			b.WriteString("-NUM")
+		}
+		if hasDash {
+			b.WriteString("-DASH")
+		}
+
+		n := utf8.RuneCountInString(lower)
+		switch {
+		case strings.HasSuffix(lower, "s") && n >= 3:
+			// plurals, but not -ss, -is and -us
+			runes := []rune(lower)
+			if !strings.ContainsRune("siu", runes[n-2]) {
+				b.WriteString("-s")
+			}
+		case n >= 5 && !hasDash && !(hasDigit && numCaps > 0):
+			for _, suffix := range berkeleySuffixes {
+				if strings.HasSuffix(lower, suffix) {
+					b.WriteString("-" + suffix)
+					break
+				}
+			}
+		}
+		return b.String()
+	}
+}
+
+// WordShape is a Signature that maps uppercase letters to X, other letters to x and digits to d, and keeps everything else.
+// Runs of the same class are cut at 4, so "Hello" and "Washington" are both "Xxxxx", and "12-34" is "dd-dd".
+func WordShape(word string, i int) string { return wordShape(word, 4) }
+
+// CompactWordShape is like WordShape, but runs of the same class are cut at 1, so "Hello" is "Xx" and "12-34" is "d-d".
+func CompactWordShape(word string, i int) string { return wordShape(word, 1) }
+
+func wordShape(word string, maxRun int) string {
+	var b strings.Builder
+	var prev rune
+	var run int
+	for _, r := range word {
+		var c rune
+		switch {
+		case unicode.IsUpper(r) || unicode.IsTitle(r):
+			c = 'X'
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			c = 'x'
+		case unicode.IsDigit(r):
+			c = 'd'
+		default:
+			c = r
+		}
+		if c == prev {
+			run++
+		} else {
+			prev, run = c, 1
+		}
+		if run <= maxRun {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// AddSignatures adds a special entry for the class of every word of the corpus whose frequency is below minFreq. The frequency of each
+// entry is the sum of the frequencies of the words in its class. As the positions of the words are not known, i is -1.
+// Entries are special (see IsSpecial), so they are never classified themselves, and calling AddSignatures again with the same arguments changes nothing.
+// A class whose entry is already an ordinary word of the corpus is skipped. It returns the number of classes that were added.
+func (c *Corpus) AddSignatures(sig Signature, minFreq int) int {
+	var classes []string
+	sums := make(map[string]int)
+	for id, w := range c.words {
+		if c.IsSpecial(w) || c.frequencies[id] >= minFreq {
+			continue
+		}
+		entry := signatureEntry(sig(w, -1))
+		if _, ok := sums[entry]; !ok {
+			classes = append(classes, entry)
+		}
+		sums[entry] += c.frequencies[id]
+	}
+
+	before := c.Size()
+	for _, entry := range classes {
+		if _, ok := c.signatures[entry]; ok {
+			id := c.ids[entry]
+			c.totalFreq += sums[entry] - c.frequencies[id]
+			c.frequencies[id] = sums[entry]
+			continue
+		}
+		c.addSignature(entry, sums[entry])
+	}
+	return c.Size() - before
+}
+
+// AddSentenceSignatures adds a special entry for the class of every occurrence in the sentences of a word that is unknown, or whose frequency is below minFreq.
+// Each occurrence adds 1 to the frequency of its class. A class whose entry is already an ordinary word of the corpus is skipped.
+// It returns the number of classes that were added.
+func (c *Corpus) AddSentenceSignatures(sentences [][]string, sig Signature, minFreq int) int {
+	before := c.Size()
+	for _, sentence := range sentences {
+		for i, w := range sentence {
+			if c.isRare(w, minFreq) {
+				c.addSignature(signatureEntry(sig(w, i)), 1)
+			}
+		}
+	}
+	return c.Size() - before
+}
+
+// addSignature adds n occurrences of the entry of a class, and marks it as special. Like the special words added by New, it doesn't count towards the maximum word length.
+// If the entry is already an ordinary word, nothing is added.
+func (c *Corpus) addSignature(entry string, n int) {
+	_, isSignature := c.signatures[entry]
+	if _, ok := c.ids[entry]; ok && !isSignature {
+		return
+	}
+	if c.signatures == nil {
+		c.signatures = make(map[string]struct{})
+	}
+	c.signatures[entry] = struct{}{}
+	maxWordLength := c.maxWordLength
+	c.AddN(entry, n)
+	c.maxWordLength = maxWordLength
+}
+
+// EncodeWithSignatures returns the IDs of the words of a sentence. Words that are unknown, or whose frequency is below minFreq, are given the ID of their class instead.
+// If the class has no entry in the corpus, -UNKNOWN- is used, or -1 if the corpus doesn't have it (as with Construct).
+func (c *Corpus) EncodeWithSignatures(sentence []string, sig Signature, minFreq int) []int {
+	unk, ok := c.Id("-UNKNOWN-")
+	if !ok {
+		unk = -1
+	}
+	retVal := make([]int, len(sentence))
+	for i, w := range sentence {
+		if !c.isRare(w, minFreq) {
+			retVal[i], _ = c.Id(w)
+			continue
+		}
+		entry := signatureEntry(sig(w, i))
+		id, ok := c.Id(entry)
+		if _, isSignature := c.signatures[entry]; !ok || !isSignature {
+			id = unk
+		}
+		retVal[i] = id
+	}
+	return retVal
+}
+
+// isRare reports whether a word is unknown or seen fewer than minFreq times. Special words, including the entries of classes, are never rare.
+func (c *Corpus) isRare(w string, minFreq int) bool {
+	if c.IsSpecial(w) {
+		return false
+	}
+	id, ok := c.Id(w)
+	return !ok || c.frequencies[id] < minFreq
+}
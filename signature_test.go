@@ -0,0 +1,187 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBerkeleySignature(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.Add("running")
+	sig := BerkeleySignature(c)
+
+	assert.Equal("UNK-INITC-ing", sig("Blarging", 0))
+	assert.Equal("UNK-INITC-KNOWNLC-ing", sig("Running", 0))
+	assert.Equal("UNK-CAPS-ing", sig("Blarging", 3))
+	assert.Equal("UNK-CAPS", sig("NASA", 0))
+	assert.Equal("UNK-CAPS-NUM-DASH", sig("3-D", 2))
+	assert.Equal("UNK-LC-s", sig("florbs", 1))
+	assert.Equal("UNK-LC", sig("glass", 1))
+	assert.Equal("UNK-LC-ly", sig("quickly", 1))
+	assert.Equal("UNK-LC-DASH", sig("well-formed", 1))
+	assert.Equal("UNK-NUM", sig("1984", 1))
+	assert.Equal("UNK", sig("%", 1))
+
+	// without a vocabulary, KNOWNLC is never marked
+	assert.Equal("UNK-INITC-ing", BerkeleySignature(nil)("Running", 0))
+}
+
+func TestWordShape(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("Xxxxx", WordShape("Hello", 0))
+	assert.Equal("Xxxxx", WordShape("Washington", 0))
+	assert.Equal("dd-dd", WordShape("12-34", 0))
+	assert.Equal("dddd-dd-dd", WordShape("2015-10-18", 0))
+	assert.Equal("X.X.", WordShape("U.S.", 0))
+	assert.Equal("xxxx", WordShape("café", 0))
+
+	assert.Equal("Xx", CompactWordShape("Hello", 0))
+	assert.Equal("d-d", CompactWordShape("12-34", 0))
+	assert.Equal("xXxX", CompactWordShape("iPhoneX", 0))
+}
+
+func TestCorpus_Signatures(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.AddN("the", 10)
+	c.AddN("cat", 5)
+	c.Add("Paris")
+	c.Add("London")
+	c.Add("1984")
+
+	added := c.AddSignatures(WordShape, 2)
+	assert.Equal(2, added)
+	id, ok := c.Id("-Xxxxx-")
+	assert.True(ok)
+	assert.Equal(2, c.WordFreq("-Xxxxx-"))
+	assert.Equal(1, c.WordFreq("-dddd-"))
+
+	unk, _ := c.Id("-UNKNOWN-")
+	the, _ := c.Id("the")
+	paris, _ := c.Id("Paris")
+	digits, _ := c.Id("-dddd-")
+	assert.NotEqual(paris, id)
+
+	// rare and unknown words are given the IDs of their classes, and classes that aren't there fall back to -UNKNOWN-
+	assert.Equal([]int{the, id, id, digits, unk}, c.EncodeWithSignatures([]string{"the", "Paris", "Berlin", "2001", "dog"}, WordShape, 2))
+	// with a threshold of 1, words seen once are kept
+	assert.Equal([]int{paris, id}, c.EncodeWithSignatures([]string{"Paris", "Berlin"}, WordShape, 1))
+	// special words are never replaced
+	root, _ := c.Id("-ROOT-")
+	assert.Equal([]int{root}, c.EncodeWithSignatures([]string{"-ROOT-"}, WordShape, 100))
+}
+
+func TestCorpus_AddSentenceSignatures(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.AddN("the", 10)
+	c.AddN("cat", 5)
+	sig := BerkeleySignature(c)
+
+	sentences := [][]string{
+		{"Blarging", "the", "cat"},
+		{"the", "cat", "florbs"},
+		{"the", "zorbs"},
+	}
+	added := c.AddSentenceSignatures(sentences, sig, 2)
+	assert.Equal(2, added)
+	assert.Equal(1, c.WordFreq("-UNK-INITC-ing-"))
+	assert.Equal(2, c.WordFreq("-UNK-LC-s-"))
+
+	initc, _ := c.Id("-UNK-INITC-ing-")
+	plural, _ := c.Id("-UNK-LC-s-")
+	unk, _ := c.Id("-UNKNOWN-")
+	the, _ := c.Id("the")
+	// mid sentence, a capitalized word is in another class, which was not seen
+	assert.Equal([]int{initc, the, plural, unk}, c.EncodeWithSignatures([]string{"Frobbing", "the", "glorps", "Frobbing"}, sig, 2))
+}
+
+func TestCorpus_Signatures_Special(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.AddN("the", 10)
+	c.Add("Paris")
+	c.Add("1984")
+	total := c.TotalFreq()
+
+	assert.Equal(2, c.AddSignatures(WordShape, 2))
+	assert.True(c.IsSpecial("-Xxxxx-"))
+	assert.False(c.IsSpecial("Paris"))
+	// entries don't count towards the maximum word length
+	assert.Equal(5, c.MaxWordLength())
+	assert.Equal(total+2, c.TotalFreq())
+	assert.NoError(c.Validate())
+
+	// calling it again changes nothing: entries are not classified themselves
+	size := c.Size()
+	assert.Equal(0, c.AddSignatures(WordShape, 2))
+	assert.Equal(size, c.Size())
+	assert.Equal(total+2, c.TotalFreq())
+	assert.Equal(1, c.WordFreq("-Xxxxx-"))
+	_, ok := c.Id("--Xxxxx--")
+	assert.False(ok)
+
+	// entries survive encoding, and are merged as entries
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(c))
+	decoded := new(Corpus)
+	require.NoError(t, gob.NewDecoder(&buf).Decode(decoded))
+	assert.True(decoded.IsSpecial("-Xxxxx-"))
+	assert.Equal(5, decoded.MaxWordLength())
+
+	merged := New()
+	merged.Merge(c)
+	assert.True(merged.IsSpecial("-dddd-"))
+	assert.Equal(5, merged.MaxWordLength())
+
+	// and they are left out of training
+	b, err := TrainBPE(c, 100)
+	require.NoError(t, err)
+	_, ok = b.Vocab().Id("X")
+	assert.False(ok)
+}
+
+func TestCorpus_EncodeWithSignatures_NoUnknown(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"3-D", "the", "the"}))
+	require.NoError(t, err)
+
+	// without -UNKNOWN-, words whose class has no entry are encoded as -1
+	the, _ := c.Id("the")
+	assert.Equal([]int{the, -1}, c.EncodeWithSignatures([]string{"the", "Foobing"}, WordShape, 2))
+
+	c.AddSentenceSignatures([][]string{{"Foobing"}}, WordShape, 2)
+	xx, ok := c.Id("-Xxxxx-")
+	require.True(t, ok)
+	assert.Equal([]int{the, xx}, c.EncodeWithSignatures([]string{"the", "Foobing"}, WordShape, 2))
+}
+
+func TestCorpus_Signatures_Collision(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.AddN("-Xxxxx-", 5)
+	c.Add("Hello")
+	total := c.TotalFreq()
+
+	// an ordinary word that looks like an entry is left alone
+	assert.Equal(0, c.AddSignatures(WordShape, 2))
+	assert.Equal(0, c.AddSentenceSignatures([][]string{{"World"}}, WordShape, 2))
+	assert.Equal(5, c.WordFreq("-Xxxxx-"))
+	assert.Equal(total, c.TotalFreq())
+	assert.False(c.IsSpecial("-Xxxxx-"))
+
+	// and rare words are not encoded as it
+	unk, _ := c.Id("-UNKNOWN-")
+	assert.Equal([]int{unk}, c.EncodeWithSignatures([]string{"World"}, WordShape, 2))
+
+	other := New()
+	other.AddSentenceSignatures([][]string{{"World"}}, WordShape, 2)
+	c.Merge(other)
+	assert.Equal(5, c.WordFreq("-Xxxxx-"))
+	assert.False(c.IsSpecial("-Xxxxx-"))
+}
@@ -0,0 +1,117 @@
+package corpus
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Token is a token of a text, with the position in the text it comes from. Positions are kept when tokens are encoded and split into subwords,
+// so that whatever is predicted for a token (an entity label, say) can be mapped back onto the text.
+type Token struct {
+	Text               string // the token, which may differ from the text it comes from when it was replaced or is a subword
+	Start, End         int    // the byte offsets of the text the token comes from
+	RuneStart, RuneEnd int    // the rune offsets of the text the token comes from
+	ID                 int    // the ID of the token, or -1 if it has none
+}
+
+// AlignTokens finds the tokens in the text, in order, and returns them with their offsets. It gives positions to the tokens of any tokenizer
+// that returns parts of its input, such as DictTokenizer or (*IdentifierSplitter).Tokenize. A token that isn't found gets an empty span where the previous token ended.
+func AlignTokens(text string, tokens []string) []Token {
+	retVal := make([]Token, len(tokens))
+	pos, runePos := 0, 0
+	for i, t := range tokens {
+		start, end := pos, pos
+		if j := strings.Index(text[pos:], t); j >= 0 {
+			start, end = pos+j, pos+j+len(t)
+		}
+		runeStart := runePos + utf8.RuneCountInString(text[pos:start])
+		runeEnd := runeStart + utf8.RuneCountInString(text[start:end])
+		retVal[i] = Token{Text: t, Start: start, End: end, RuneStart: runeStart, RuneEnd: runeEnd, ID: -1}
+		pos, runePos = end, runeEnd
+	}
+	return retVal
+}
+
+// EncodeTokens returns a copy of the tokens with their IDs in the vocabulary. Unknown tokens get the ID of -UNKNOWN-, or -1 if the vocabulary doesn't have it.
+func EncodeTokens(v Vocabulary, tokens []Token) []Token {
+	unk, ok := v.Id("-UNKNOWN-")
+	if !ok {
+		unk = -1
+	}
+	retVal := make([]Token, len(tokens))
+	for i, t := range tokens {
+		id, ok := v.Id(t.Text)
+		if !ok {
+			id = unk
+		}
+		t.ID = id
+		retVal[i] = t
+	}
+	return retVal
+}
+
+// TokenIDs returns the IDs of the tokens.
+func TokenIDs(tokens []Token) []int {
+	retVal := make([]int, len(tokens))
+	for i, t := range tokens {
+		retVal[i] = t.ID
+	}
+	return retVal
+}
+
+// subToken returns the token for the bytes a to b of the text of parent, such as a subword of it. If the text of parent isn't the text it comes from
+// (it was replaced, so its length differs from its span), the token gets the offsets of parent.
+func subToken(parent Token, text string, id, a, b int) Token {
+	t := Token{Text: text, Start: parent.Start, End: parent.End, RuneStart: parent.RuneStart, RuneEnd: parent.RuneEnd, ID: id}
+	if len(parent.Text) != parent.End-parent.Start || a < 0 || b > len(parent.Text) || a > b {
+		return t
+	}
+	t.Start, t.End = parent.Start+a, parent.Start+b
+	if a == b {
+		t.RuneStart = parent.RuneStart + runeStartsBefore(parent.Text, a)
+		t.RuneEnd = t.RuneStart
+		return t
+	}
+	// a piece that starts in the middle of a rune (which byte level subwords may do) starts with that rune
+	t.RuneStart = parent.RuneStart + runeStartsBefore(parent.Text, a+1) - 1
+	t.RuneEnd = parent.RuneStart + runeStartsBefore(parent.Text, b)
+	return t
+}
+
+// runeStartsBefore counts the runes that start before byte i of s.
+func runeStartsBefore(s string, i int) int {
+	if i > len(s) {
+		i = len(s)
+	}
+	retVal := 0
+	for j := 0; j < i; j++ {
+		if utf8.RuneStart(s[j]) {
+			retVal++
+		}
+	}
+	return retVal
+}
+
+// concatTokens gives subwords whose concatenation is skip bytes of prefix, the text of parent, and then a suffix (such as an end of word marker)
+// the offsets of the parts of parent they cover. Prefixes and suffixes are not part of the text, so they have empty spans.
+func concatTokens(parent Token, subwords []string, ids []int, skip int) []Token {
+	retVal := make([]Token, len(subwords))
+	pos := -skip
+	for i, s := range subwords {
+		a, b := pos, pos+len(s)
+		pos = b
+		a, b = clampInt(a, 0, len(parent.Text)), clampInt(b, 0, len(parent.Text))
+		retVal[i] = subToken(parent, s, ids[i], a, b)
+	}
+	return retVal
+}
+
+func clampInt(x, lo, hi int) int {
+	switch {
+	case x < lo:
+		return lo
+	case x > hi:
+		return hi
+	}
+	return x
+}
@@ -0,0 +1,66 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignTokens(t *testing.T) {
+	assert := assert.New(t)
+	text := "café au lait, café"
+	tokens := AlignTokens(text, []string{"café", "lait", "café", "crème"})
+	assert.Equal([]Token{
+		{Text: "café", Start: 0, End: 5, RuneStart: 0, RuneEnd: 4, ID: -1},
+		{Text: "lait", Start: 9, End: 13, RuneStart: 8, RuneEnd: 12, ID: -1},
+		{Text: "café", Start: 15, End: 20, RuneStart: 14, RuneEnd: 18, ID: -1},
+		{Text: "crème", Start: 20, End: 20, RuneStart: 18, RuneEnd: 18, ID: -1},
+	}, tokens)
+	for _, tok := range tokens[:3] {
+		assert.Equal(tok.Text, text[tok.Start:tok.End])
+		assert.Equal(tok.Text, string([]rune(text)[tok.RuneStart:tok.RuneEnd]))
+	}
+	assert.Empty(AlignTokens("", nil))
+}
+
+func TestWordTokenizer_Tokens(t *testing.T) {
+	assert := assert.New(t)
+	text := "Zoë's 3 cafés!"
+	tokens := NewWordTokenizer(SplitContractions(), ReplaceNumbers("-NUM-")).Tokens(text)
+	assert.Equal([]Token{
+		{Text: "Zoë", Start: 0, End: 4, RuneStart: 0, RuneEnd: 3, ID: -1},
+		{Text: "'s", Start: 4, End: 6, RuneStart: 3, RuneEnd: 5, ID: -1},
+		{Text: "-NUM-", Start: 7, End: 8, RuneStart: 6, RuneEnd: 7, ID: -1},
+		{Text: "cafés", Start: 9, End: 15, RuneStart: 8, RuneEnd: 13, ID: -1},
+	}, tokens)
+	assert.Equal([]string{"Zoë", "'s", "-NUM-", "cafés"}, NewWordTokenizer(SplitContractions(), ReplaceNumbers("-NUM-")).Tokenize(text))
+}
+
+func TestEncodeTokens(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.Add("the")
+	c.Add("cat")
+	tokens := EncodeTokens(c, NewWordTokenizer().Tokens("The cat sat"))
+	unk, _ := c.Id("-UNKNOWN-")
+	cat, _ := c.Id("cat")
+	assert.Equal([]int{unk, cat, unk}, TokenIDs(tokens))
+	assert.Equal(Token{Text: "cat", Start: 4, End: 7, RuneStart: 4, RuneEnd: 7, ID: cat}, tokens[1])
+
+	// without -UNKNOWN-, unknown tokens have no ID
+	v, _ := Construct(WithWords([]string{"cat"}))
+	assert.Equal([]int{-1, 0, -1}, TokenIDs(EncodeTokens(v, NewWordTokenizer().Tokens("The cat sat"))))
+}
+
+func TestSubToken(t *testing.T) {
+	assert := assert.New(t)
+	parent := Token{Text: "héllo", Start: 10, End: 16, RuneStart: 8, RuneEnd: 13}
+	assert.Equal(Token{Text: "é", Start: 11, End: 13, RuneStart: 9, RuneEnd: 10, ID: 3}, subToken(parent, "é", 3, 1, 3))
+	// the second byte of é
+	assert.Equal(Token{Text: "x", Start: 12, End: 13, RuneStart: 9, RuneEnd: 10, ID: 3}, subToken(parent, "x", 3, 2, 3))
+	assert.Equal(Token{Text: "", Start: 13, End: 13, RuneStart: 10, RuneEnd: 10, ID: 3}, subToken(parent, "", 3, 3, 3))
+
+	// replaced tokens can't be split, so every part spans the whole token
+	parent.Text = "-NUM-"
+	assert.Equal(Token{Text: "x", Start: 10, End: 16, RuneStart: 8, RuneEnd: 13, ID: 3}, subToken(parent, "x", 3, 1, 2))
+}
@@ -0,0 +1,210 @@
+package corpus
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordTokenizerOpt is an option for NewWordTokenizer.
+type WordTokenizerOpt func(t *WordTokenizer)
+
+// KeepPunctuation keeps punctuation and symbols as tokens of their own. By default they are dropped.
+func KeepPunctuation() WordTokenizerOpt {
+	return func(t *WordTokenizer) { t.punctuation = true }
+}
+
+// SplitContractions splits English clitics off the words they are attached to, the way the Penn Treebank does: "don't" becomes "do" and "n't",
+// and "Alice's" becomes "Alice" and "'s". By default words with apostrophes are kept whole.
+func SplitContractions() WordTokenizerOpt {
+	return func(t *WordTokenizer) { t.contractions = true }
+}
+
+// JoinHyphenated keeps hyphenated words ("daisy-chain", "mother-in-law") as one token. By default they are split at the hyphens, which are punctuation.
+func JoinHyphenated() WordTokenizerOpt {
+	return func(t *WordTokenizer) { t.hyphenated = true }
+}
+
+// ReplaceNumbers replaces every number ("42", "3.0", "1,000") with the given token, such as "-NUM-". Numbers are kept by default.
+func ReplaceNumbers(token string) WordTokenizerOpt {
+	return func(t *WordTokenizer) { t.numbers, t.numberToken = replaceNumbers, token }
+}
+
+// DropNumbers drops numbers.
+func DropNumbers() WordTokenizerOpt {
+	return func(t *WordTokenizer) { t.numbers = dropNumbers }
+}
+
+const (
+	keepNumbers = iota
+	replaceNumbers
+	dropNumbers
+)
+
+// clitics are the English clitics split off by SplitContractions, lowercased, with a straight apostrophe.
+var clitics = []string{"n't", "'s", "'re", "'ve", "'ll", "'d", "'m"}
+
+// WordTokenizer splits text into words at the word boundaries of Unicode Standard Annex #29. Unlike splitting on spaces, punctuation is not
+// left attached to words ("Wonderland," is "Wonderland"), while words with apostrophes ("ALICE'S") and numbers with separators ("3.0") are kept whole.
+//
+// Letters of scripts written without spaces are tokens of their own: ideographs, Hiragana, Thai, Lao, Khmer and Burmese. Use DictTokenizer to split such text into words.
+type WordTokenizer struct {
+	punctuation  bool
+	contractions bool
+	hyphenated   bool
+	numbers      int
+	numberToken  string
+}
+
+// NewWordTokenizer creates a new *WordTokenizer. By default it keeps words and numbers, and drops spaces and punctuation.
+func NewWordTokenizer(opts ...WordTokenizerOpt) *WordTokenizer {
+	t := new(WordTokenizer)
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Tokenize splits the text into tokens. It may be passed to FromTextCorpus as its tokenizer, and is its default.
+func (t *WordTokenizer) Tokenize(a string) []string {
+	spans := t.spans(a)
+	retVal := make([]string, len(spans))
+	for i, s := range spans {
+		retVal[i] = s.text
+	}
+	return retVal
+}
+
+// Tokens splits the text into tokens, with their offsets. Tokens have no IDs yet; see EncodeTokens.
+// Replaced numbers keep the offsets of the numbers they replace.
+func (t *WordTokenizer) Tokens(a string) []Token {
+	spans := t.spans(a)
+	retVal := make([]Token, len(spans))
+	pos, runePos := 0, 0
+	for i, s := range spans {
+		runeStart := runePos + utf8.RuneCountInString(a[pos:s.start])
+		runeEnd := runeStart + utf8.RuneCountInString(a[s.start:s.end])
+		retVal[i] = Token{Text: s.text, Start: s.start, End: s.end, RuneStart: runeStart, RuneEnd: runeEnd, ID: -1}
+		pos, runePos = s.end, runeEnd
+	}
+	return retVal
+}
+
+// SplitOnSpaces is the tokenizer FromTextCorpus used to default to: the text is trimmed, and split at every single space.
+// Punctuation stays attached to words, and runs of spaces give empty tokens.
+func SplitOnSpaces(a string) []string {
+	return strings.Split(strings.Trim(a, "\r\n "), " ")
+}
+
+// span is a token, and the byte offsets of the text it comes from. The text of the token differs from the text at the offsets when it is replaced.
+type span struct {
+	text       string
+	start, end int
+}
+
+// segment kinds
+const (
+	spaceSegment = iota
+	wordSegment
+	numberSegment
+	punctSegment
+)
+
+// spans returns the tokens of the text with their offsets.
+func (t *WordTokenizer) spans(a string) []span {
+	segments := wordSegments(a)
+	kinds := make([]int, len(segments))
+	for i, s := range segments {
+		kinds[i] = segmentKind(a[s[0]:s[1]])
+	}
+
+	retVal := make([]span, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		start, end := segments[i][0], segments[i][1]
+		kind := kinds[i]
+
+		if t.hyphenated && (kind == wordSegment || kind == numberSegment) {
+			// join word - word - word, as long as there is nothing between them
+			for i+2 < len(segments) && isHyphen(a[segments[i+1][0]:segments[i+1][1]]) && (kinds[i+2] == wordSegment || kinds[i+2] == numberSegment) {
+				if kinds[i+2] == wordSegment {
+					kind = wordSegment
+				}
+				end = segments[i+2][1]
+				i += 2
+			}
+		}
+
+		switch kind {
+		case spaceSegment:
+			continue
+		case punctSegment:
+			if t.punctuation {
+				retVal = append(retVal, span{a[start:end], start, end})
+			}
+			continue
+		case numberSegment:
+			switch t.numbers {
+			case replaceNumbers:
+				retVal = append(retVal, span{t.numberToken, start, end})
+				continue
+			case dropNumbers:
+				continue
+			}
+		}
+
+		if t.contractions {
+			if cut := cliticStart(a[start:end]); cut > 0 {
+				retVal = append(retVal, span{a[start : start+cut], start, start + cut}, span{a[start+cut : end], start + cut, end})
+				continue
+			}
+		}
+		retVal = append(retVal, span{a[start:end], start, end})
+	}
+	return retVal
+}
+
+// segmentKind tells words, numbers, spaces and punctuation apart. Segments with letters are words, and segments with digits but no letters are numbers.
+func segmentKind(s string) int {
+	var hasLetter, hasDigit, hasOther bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasOther = true
+		}
+	}
+	switch {
+	case hasLetter:
+		return wordSegment
+	case hasDigit:
+		return numberSegment
+	case hasOther:
+		return punctSegment
+	}
+	return spaceSegment
+}
+
+// isHyphen reports whether the segment is a single hyphen.
+func isHyphen(s string) bool {
+	return s == "-" || s == "‐" || s == "‑"
+}
+
+// cliticStart returns the byte offset at which a clitic starts in the word, or 0 if the word doesn't end with one. Curly apostrophes are clitics too.
+func cliticStart(word string) int {
+	lower := strings.ToLower(strings.ReplaceAll(word, "’", "'"))
+	for _, c := range clitics {
+		if len(lower) > len(c) && strings.HasSuffix(lower, c) {
+			// the clitic is as many runes long in the word, whichever apostrophe it has
+			cut := len(word)
+			for n := utf8.RuneCountInString(c); n > 0; n-- {
+				_, size := utf8.DecodeLastRuneInString(word[:cut])
+				cut -= size
+			}
+			return cut
+		}
+	}
+	return 0
+}
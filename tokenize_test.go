@@ -0,0 +1,67 @@
+package corpus
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordTokenizer(t *testing.T) {
+	assert := assert.New(t)
+	text := "`Oh dear!  I shall be late!' ALICE'S Rabbit-Hole, 3.0 don't"
+
+	assert.Equal([]string{"Oh", "dear", "I", "shall", "be", "late", "ALICE'S", "Rabbit", "Hole", "3.0", "don't"}, NewWordTokenizer().Tokenize(text))
+	assert.Equal([]string{"`", "Oh", "dear", "!", "I", "shall", "be", "late", "!", "'", "ALICE'S", "Rabbit", "-", "Hole", ",", "3.0", "don't"},
+		NewWordTokenizer(KeepPunctuation()).Tokenize(text))
+	assert.Equal([]string{"Oh", "dear", "I", "shall", "be", "late", "ALICE", "'S", "Rabbit", "Hole", "3.0", "do", "n't"},
+		NewWordTokenizer(SplitContractions()).Tokenize(text))
+	assert.Equal([]string{"Oh", "dear", "I", "shall", "be", "late", "ALICE'S", "Rabbit-Hole", "-NUM-", "don't"},
+		NewWordTokenizer(JoinHyphenated(), ReplaceNumbers("-NUM-")).Tokenize(text))
+	assert.Equal([]string{"a", "b"}, NewWordTokenizer(DropNumbers()).Tokenize("a 42 b 1,000"))
+
+	// hyphenated words need words on both sides of every hyphen
+	tok := NewWordTokenizer(JoinHyphenated(), KeepPunctuation())
+	assert.Equal([]string{"mother-in-law", "WAISTCOAT", "-", "pre", "-", "-", "war", "COVID-19"}, tok.Tokenize("mother-in-law WAISTCOAT- pre--war COVID-19"))
+	// curly apostrophes are clitics too
+	assert.Equal([]string{"Alice", "’s", "wo", "n’t"}, NewWordTokenizer(SplitContractions()).Tokenize("Alice’s won’t"))
+	// without a word to attach to, an apostrophe is punctuation
+	assert.Equal([]string{"'", "s"}, NewWordTokenizer(SplitContractions(), KeepPunctuation()).Tokenize("'s"))
+
+	assert.Empty(NewWordTokenizer().Tokenize("  ... --  "))
+}
+
+func TestSplitOnSpaces(t *testing.T) {
+	assert.Equal(t, []string{"Down", "the", "", "Rabbit-Hole,"}, SplitOnSpaces("  Down the  Rabbit-Hole,\r\n"))
+}
+
+func TestFromTextCorpus_DefaultTokenizer(t *testing.T) {
+	assert := assert.New(t)
+	f, err := os.Open("testdata/corpus_en.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	c, err := FromTextCorpus(f, nil, strings.ToLower)
+	require.NoError(t, err)
+
+	for _, w := range []string{"alice's", "wonderland", "rabbit", "hole", "3.0"} {
+		_, ok := c.Id(w)
+		assert.True(ok, "%q", w)
+	}
+	for _, w := range []string{"wonderland,", "rabbit-hole", "`and", "!"} {
+		_, ok := c.Id(w)
+		assert.False(ok, "%q", w)
+	}
+	// runs of spaces don't give empty words
+	empty := c.WordFreq("")
+
+	// the old behaviour is still there
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	c, err = FromTextCorpus(f, SplitOnSpaces, strings.ToLower)
+	require.NoError(t, err)
+	_, ok := c.Id("wonderland,")
+	assert.True(ok)
+	assert.True(c.WordFreq("") > empty)
+}
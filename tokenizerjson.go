@@ -0,0 +1,563 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// AddedToken is a token that the Hugging Face tokenizers library matches in text before any other processing, such as "[CLS]" or "<|endoftext|>".
+type AddedToken struct {
+	ID         int    `json:"id"`
+	Content    string `json:"content"`
+	SingleWord bool   `json:"single_word"`
+	LStrip     bool   `json:"lstrip"`
+	RStrip     bool   `json:"rstrip"`
+	Normalized bool   `json:"normalized"`
+	Special    bool   `json:"special"`
+}
+
+// NormalizerSettings are the settings of the normalizers of a tokenizer.json that this package understands.
+// Other normalizers (such as SentencePiece's precompiled character maps) are not described, but are kept when the file is saved again.
+type NormalizerSettings struct {
+	Lowercase          bool
+	StripAccents       bool
+	CleanText          bool   // remove control characters and normalize whitespace, as BERT does
+	HandleChineseChars bool   // put spaces around CJK ideographs, as BERT does
+	Unicode            string // the Unicode normalization form, "NFC", "NFD", "NFKC" or "NFKD". Empty if there is none.
+}
+
+// TokenizerJSON is a tokenizer serialized by the Hugging Face tokenizers library (a tokenizer.json file). Models of type "WordPiece", "BPE" and "Unigram" are supported.
+//
+// The IDs of Vocab are the IDs of the tokenizer: the tokens of the model's vocabulary, and the added tokens. Like FromDict, loading requires the IDs to run from 0
+// without gaps.
+type TokenizerJSON struct {
+	Type   string    // "WordPiece", "BPE" or "Unigram"
+	Vocab  *Corpus   // every token, including the added tokens
+	Scores []float64 // the log probabilities of the tokens of a Unigram model, by ID. If nil when saving, they are computed from the frequencies of Vocab.
+	Merges []Merge   // the merges of a BPE model, in order of priority
+
+	UnkToken                string // the unknown token. It may be empty for BPE models.
+	ContinuingSubwordPrefix string // "##" for WordPiece
+	EndOfWordSuffix         string // BPE only
+	MaxInputCharsPerWord    int    // WordPiece only. If 0 when saving, it is 100.
+	ByteFallback            bool   // whether unknown runes are encoded as byte tokens such as "<0x41>" (BPE and Unigram)
+
+	AddedTokens []AddedToken
+	Normalizer  NormalizerSettings
+
+	// the other components of the tokenizer, which are kept as they are. When saving, a nil PreTokenizer is BertPreTokenizer for WordPiece models.
+	PreTokenizer  json.RawMessage
+	PostProcessor json.RawMessage
+	Decoder       json.RawMessage
+
+	addedOnly        map[string]bool // the added tokens that weren't in the vocabulary of the model when it was loaded
+	normalizer       json.RawMessage // the normalizer as loaded
+	loadedNormalizer NormalizerSettings
+	truncation       json.RawMessage
+	padding          json.RawMessage
+}
+
+type tokenizerFile struct {
+	Version       string          `json:"version"`
+	Truncation    json.RawMessage `json:"truncation"`
+	Padding       json.RawMessage `json:"padding"`
+	AddedTokens   []AddedToken    `json:"added_tokens"`
+	Normalizer    json.RawMessage `json:"normalizer"`
+	PreTokenizer  json.RawMessage `json:"pre_tokenizer"`
+	PostProcessor json.RawMessage `json:"post_processor"`
+	Decoder       json.RawMessage `json:"decoder"`
+	Model         json.RawMessage `json:"model"`
+}
+
+// tokenizerModel holds the fields of all the supported models. Fields that may be null are pointers.
+type tokenizerModel struct {
+	Type                    string          `json:"type"`
+	UnkToken                *string         `json:"unk_token"`
+	UnkID                   *int            `json:"unk_id"`
+	ContinuingSubwordPrefix *string         `json:"continuing_subword_prefix"`
+	EndOfWordSuffix         *string         `json:"end_of_word_suffix"`
+	MaxInputCharsPerWord    int             `json:"max_input_chars_per_word"`
+	ByteFallback            bool            `json:"byte_fallback"`
+	Vocab                   json.RawMessage `json:"vocab"`
+	Merges                  json.RawMessage `json:"merges"`
+}
+
+type normalizerJSON struct {
+	Type               string           `json:"type"`
+	Normalizers        []normalizerJSON `json:"normalizers"`
+	Lowercase          bool             `json:"lowercase"`
+	StripAccents       *bool            `json:"strip_accents"`
+	CleanText          bool             `json:"clean_text"`
+	HandleChineseChars bool             `json:"handle_chinese_chars"`
+}
+
+// LoadTokenizerJSON reads a tokenizer.json.
+func LoadTokenizerJSON(r io.Reader) (*TokenizerJSON, error) {
+	var f tokenizerFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, errors.Wrap(err, "Cannot decode tokenizer.json")
+	}
+	var m tokenizerModel
+	if err := json.Unmarshal(f.Model, &m); err != nil {
+		return nil, errors.Wrap(err, "Cannot decode the model of tokenizer.json")
+	}
+
+	t := &TokenizerJSON{
+		Type:                 m.Type,
+		MaxInputCharsPerWord: m.MaxInputCharsPerWord,
+		ByteFallback:         m.ByteFallback,
+		AddedTokens:          f.AddedTokens,
+		PreTokenizer:         nullable(f.PreTokenizer),
+		PostProcessor:        nullable(f.PostProcessor),
+		Decoder:              nullable(f.Decoder),
+		normalizer:           nullable(f.Normalizer),
+		truncation:           nullable(f.Truncation),
+		padding:              nullable(f.Padding),
+	}
+	if t.Type == "" {
+		// older files don't say what the model is
+		switch {
+		case len(m.Merges) > 0:
+			t.Type = "BPE"
+		case bytes.HasPrefix(bytes.TrimSpace(m.Vocab), []byte("[")):
+			t.Type = "Unigram"
+		default:
+			t.Type = "WordPiece"
+		}
+	}
+	if m.UnkToken != nil {
+		t.UnkToken = *m.UnkToken
+	}
+	if m.ContinuingSubwordPrefix != nil {
+		t.ContinuingSubwordPrefix = *m.ContinuingSubwordPrefix
+	}
+	if m.EndOfWordSuffix != nil {
+		t.EndOfWordSuffix = *m.EndOfWordSuffix
+	}
+
+	// the vocabulary of the model
+	ids := make(map[string]int)
+	switch t.Type {
+	case "WordPiece", "BPE":
+		if err := json.Unmarshal(m.Vocab, &ids); err != nil {
+			return nil, errors.Wrapf(err, "Cannot decode the vocabulary of the %v model", t.Type)
+		}
+	case "Unigram":
+		var pieces [][2]interface{}
+		if err := json.Unmarshal(m.Vocab, &pieces); err != nil {
+			return nil, errors.Wrap(err, "Cannot decode the vocabulary of the Unigram model")
+		}
+		t.Scores = make([]float64, len(pieces))
+		for i, p := range pieces {
+			piece, ok1 := p[0].(string)
+			score, ok2 := p[1].(float64)
+			if !ok1 || !ok2 {
+				return nil, errors.Errorf("Cannot decode piece %d of the Unigram model. Expected a string and a score. Got %v instead", i, p)
+			}
+			ids[piece] = i
+			t.Scores[i] = score
+		}
+		if m.UnkID != nil {
+			if *m.UnkID < 0 || *m.UnkID >= len(pieces) {
+				return nil, errors.Errorf("The unknown piece's ID %d is not in the vocabulary of %d pieces", *m.UnkID, len(pieces))
+			}
+			t.UnkToken = pieces[*m.UnkID][0].(string)
+		}
+	default:
+		return nil, errors.Errorf("Cannot load a %v model. Only WordPiece, BPE and Unigram models are supported", t.Type)
+	}
+
+	if t.Type == "BPE" && len(m.Merges) > 0 {
+		merges, err := decodeMerges(m.Merges)
+		if err != nil {
+			return nil, err
+		}
+		t.Merges = merges
+	}
+
+	// added tokens that the model doesn't know come after the model's vocabulary
+	t.addedOnly = make(map[string]bool)
+	for _, a := range t.AddedTokens {
+		id, ok := ids[a.Content]
+		if ok && id != a.ID {
+			return nil, errors.Errorf("Added token %q has ID %d, but it is %d in the vocabulary of the model", a.Content, a.ID, id)
+		}
+		if !ok {
+			t.addedOnly[a.Content] = true
+			ids[a.Content] = a.ID
+		}
+	}
+	vocab, err := Construct(FromDict(ids))
+	if err != nil {
+		return nil, err
+	}
+	t.Vocab = vocab
+	if t.Scores != nil {
+		for len(t.Scores) < vocab.Size() {
+			t.Scores = append(t.Scores, 0)
+		}
+	}
+
+	if t.normalizer != nil {
+		var n normalizerJSON
+		if err := json.Unmarshal(t.normalizer, &n); err != nil {
+			return nil, errors.Wrap(err, "Cannot decode the normalizer of tokenizer.json")
+		}
+		t.Normalizer.read(n)
+	}
+	t.loadedNormalizer = t.Normalizer
+	return t, nil
+}
+
+// decodeMerges decodes merges written either as "left right" strings or as [left, right] pairs.
+func decodeMerges(raw json.RawMessage) ([]Merge, error) {
+	var pairs [][2]string
+	if err := json.Unmarshal(raw, &pairs); err == nil {
+		retVal := make([]Merge, len(pairs))
+		for i, p := range pairs {
+			retVal[i] = Merge{p[0], p[1]}
+		}
+		return retVal, nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return nil, errors.Wrap(err, "Cannot decode the merges of the BPE model")
+	}
+	retVal := make([]Merge, len(lines))
+	for i, l := range lines {
+		parts := strings.Split(l, " ")
+		if len(parts) != 2 {
+			return nil, errors.Errorf("Cannot decode merge %d: %q. Expected two tokens separated by a space", i, l)
+		}
+		retVal[i] = Merge{parts[0], parts[1]}
+	}
+	return retVal, nil
+}
+
+func (s *NormalizerSettings) read(n normalizerJSON) {
+	switch n.Type {
+	case "Sequence":
+		for _, sub := range n.Normalizers {
+			s.read(sub)
+		}
+	case "BertNormalizer":
+		s.CleanText = n.CleanText
+		s.HandleChineseChars = n.HandleChineseChars
+		s.Lowercase = n.Lowercase
+		// a null strip_accents follows lowercase
+		s.StripAccents = n.Lowercase
+		if n.StripAccents != nil {
+			s.StripAccents = *n.StripAccents
+		}
+	case "Lowercase":
+		s.Lowercase = true
+	case "StripAccents":
+		s.StripAccents = true
+	case "NFC", "NFD", "NFKC", "NFKD":
+		s.Unicode = n.Type
+	}
+}
+
+// Save writes the tokenizer as a tokenizer.json that the Hugging Face tokenizers library can load.
+func (t *TokenizerJSON) Save(w io.Writer) error {
+	model, err := t.model()
+	if err != nil {
+		return err
+	}
+	normalizer, err := t.marshalNormalizer()
+	if err != nil {
+		return err
+	}
+	preTokenizer := t.PreTokenizer
+	if preTokenizer == nil && t.Type == "WordPiece" {
+		preTokenizer = json.RawMessage(`{"type":"BertPreTokenizer"}`)
+	}
+	addedTokens := t.AddedTokens
+	if addedTokens == nil {
+		addedTokens = []AddedToken{}
+	}
+
+	f := tokenizerFile{
+		Version:       "1.0",
+		Truncation:    t.truncation,
+		Padding:       t.padding,
+		AddedTokens:   addedTokens,
+		Normalizer:    normalizer,
+		PreTokenizer:  preTokenizer,
+		PostProcessor: t.PostProcessor,
+		Decoder:       t.Decoder,
+		Model:         model,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// model marshals the model. The vocabulary of the model is every word of Vocab, but for the added tokens that weren't in it when it was loaded.
+func (t *TokenizerJSON) model() (json.RawMessage, error) {
+	if t.Vocab == nil {
+		return nil, errors.New("Cannot save a tokenizer without a vocabulary")
+	}
+	var words []string
+	var ids []int
+	for id := 0; id < t.Vocab.Size(); id++ {
+		w, _ := t.Vocab.Word(id)
+		if t.addedOnly[w] {
+			continue
+		}
+		words = append(words, w)
+		ids = append(ids, id)
+	}
+
+	switch t.Type {
+	case "WordPiece":
+		maxChars := t.MaxInputCharsPerWord
+		if maxChars == 0 {
+			maxChars = 100
+		}
+		return json.Marshal(struct {
+			Type                    string       `json:"type"`
+			UnkToken                string       `json:"unk_token"`
+			ContinuingSubwordPrefix string       `json:"continuing_subword_prefix"`
+			MaxInputCharsPerWord    int          `json:"max_input_chars_per_word"`
+			Vocab                   orderedVocab `json:"vocab"`
+		}{t.Type, t.UnkToken, t.ContinuingSubwordPrefix, maxChars, orderedVocab{words, ids}})
+	case "BPE":
+		merges := make([]string, len(t.Merges))
+		for i, m := range t.Merges {
+			merges[i] = m.Left + " " + m.Right
+		}
+		return json.Marshal(struct {
+			Type                    string       `json:"type"`
+			Dropout                 *float64     `json:"dropout"`
+			UnkToken                *string      `json:"unk_token"`
+			ContinuingSubwordPrefix *string      `json:"continuing_subword_prefix"`
+			EndOfWordSuffix         *string      `json:"end_of_word_suffix"`
+			FuseUnk                 bool         `json:"fuse_unk"`
+			ByteFallback            bool         `json:"byte_fallback"`
+			Vocab                   orderedVocab `json:"vocab"`
+			Merges                  []string     `json:"merges"`
+		}{t.Type, nil, optional(t.UnkToken), optional(t.ContinuingSubwordPrefix), optional(t.EndOfWordSuffix), false, t.ByteFallback, orderedVocab{words, ids}, merges})
+	case "Unigram":
+		for i, id := range ids {
+			if i != id {
+				return nil, errors.Errorf("Cannot save a Unigram model whose vocabulary does not start at ID 0 and run without gaps. %q has ID %d", words[i], id)
+			}
+		}
+		scores := t.Scores
+		if scores == nil {
+			scores = scoresFromFreqs(t.Vocab)
+		}
+		if len(scores) < len(words) {
+			return nil, errors.Errorf("Expected %d scores. Got %d instead", len(words), len(scores))
+		}
+		pieces := make([][2]interface{}, len(words))
+		for i, w := range words {
+			pieces[i] = [2]interface{}{w, scores[ids[i]]}
+		}
+		var unkID *int
+		if id, ok := t.Vocab.Id(t.UnkToken); ok && t.UnkToken != "" {
+			unkID = &id
+		}
+		return json.Marshal(struct {
+			Type         string           `json:"type"`
+			UnkID        *int             `json:"unk_id"`
+			Vocab        [][2]interface{} `json:"vocab"`
+			ByteFallback bool             `json:"byte_fallback"`
+		}{t.Type, unkID, pieces, t.ByteFallback})
+	}
+	return nil, errors.Errorf("Cannot save a %v model. Only WordPiece, BPE and Unigram models are supported", t.Type)
+}
+
+// marshalNormalizer writes the normalizer settings. If they haven't changed since the file was loaded, the normalizer is written as it was.
+func (t *TokenizerJSON) marshalNormalizer() (json.RawMessage, error) {
+	s := t.Normalizer
+	if s == t.loadedNormalizer && t.normalizer != nil {
+		return t.normalizer, nil
+	}
+	if s == (NormalizerSettings{}) {
+		return nil, nil
+	}
+	if s.CleanText || s.HandleChineseChars {
+		return json.Marshal(struct {
+			Type               string `json:"type"`
+			CleanText          bool   `json:"clean_text"`
+			HandleChineseChars bool   `json:"handle_chinese_chars"`
+			StripAccents       bool   `json:"strip_accents"`
+			Lowercase          bool   `json:"lowercase"`
+		}{"BertNormalizer", s.CleanText, s.HandleChineseChars, s.StripAccents, s.Lowercase})
+	}
+
+	type simple struct {
+		Type string `json:"type"`
+	}
+	var seq []simple
+	if s.Unicode != "" {
+		seq = append(seq, simple{s.Unicode})
+	}
+	if s.StripAccents {
+		if s.Unicode != "NFD" && s.Unicode != "NFKD" {
+			// accents can only be stripped from decomposed text
+			seq = append(seq, simple{"NFD"})
+		}
+		seq = append(seq, simple{"StripAccents"})
+	}
+	if s.Lowercase {
+		seq = append(seq, simple{"Lowercase"})
+	}
+	if len(seq) == 1 {
+		return json.Marshal(seq[0])
+	}
+	return json.Marshal(struct {
+		Type        string   `json:"type"`
+		Normalizers []simple `json:"normalizers"`
+	}{"Sequence", seq})
+}
+
+// WordPiece creates a WordPiece tokenizer from a WordPiece model. Special added tokens are never split.
+func (t *TokenizerJSON) WordPiece() (*WordPiece, error) {
+	if t.Type != "WordPiece" {
+		return nil, errors.Errorf("Cannot create a WordPiece tokenizer from a %v model", t.Type)
+	}
+	opts := []WordPieceOpt{WithContinuationPrefix(t.ContinuingSubwordPrefix), WithUnknownPiece(t.UnkToken), WithNeverSplit(t.specials()...)}
+	if t.MaxInputCharsPerWord > 0 {
+		opts = append(opts, WithMaxInputChars(t.MaxInputCharsPerWord))
+	}
+	if !t.Normalizer.Lowercase {
+		opts = append(opts, Cased())
+	}
+	opts = append(opts, WithStripAccents(t.Normalizer.StripAccents), WithCleanText(t.Normalizer.CleanText), WithChineseChars(t.Normalizer.HandleChineseChars))
+	if t.Normalizer.Unicode != "" {
+		form, ok := unicodeForms[t.Normalizer.Unicode]
+		if !ok {
+			return nil, errors.Errorf("Cannot create a WordPiece tokenizer with Unicode normalization %q", t.Normalizer.Unicode)
+		}
+		opts = append(opts, WithUnicodeForm(form))
+	}
+	return NewWordPiece(t.Vocab, opts...), nil
+}
+
+// unicodeForms are the Unicode normalization forms, by the names of their normalizers.
+var unicodeForms = map[string]norm.Form{"NFC": norm.NFC, "NFD": norm.NFD, "NFKC": norm.NFKC, "NFKD": norm.NFKD}
+
+// BPE creates a BPE model from a BPE model whose pre-tokenizer is not byte-level. Special added tokens are never split.
+func (t *TokenizerJSON) BPE() (*BPE, error) {
+	if t.Type != "BPE" {
+		return nil, errors.Errorf("Cannot create a BPE model from a %v model", t.Type)
+	}
+	if t.ContinuingSubwordPrefix != "" {
+		return nil, errors.New("Cannot create a BPE model with a continuing subword prefix")
+	}
+	return NewBPE(t.Vocab, t.Merges, WithEndOfWord(t.EndOfWordSuffix), WithUnknownToken(t.UnkToken), WithSpecialTokens(t.specials()...)), nil
+}
+
+// ByteLevelBPE creates a GPT-2 style byte-level BPE tokenizer from a BPE model. Special added tokens are matched in text before pre-tokenization.
+func (t *TokenizerJSON) ByteLevelBPE() (*ByteLevelBPE, error) {
+	if t.Type != "BPE" {
+		return nil, errors.Errorf("Cannot create a byte-level BPE tokenizer from a %v model", t.Type)
+	}
+	return NewByteLevelBPE(t.Vocab, t.Merges, WithSpecialTokens(t.specials()...)), nil
+}
+
+// Unigram creates a unigram model from a Unigram model. Special added tokens are never split. Neither are added tokens that the model
+// doesn't know: they have no score of their own, so they are special pieces too, and never take part in segmentation.
+func (t *TokenizerJSON) Unigram() (*Unigram, error) {
+	if t.Type != "Unigram" {
+		return nil, errors.Errorf("Cannot create a unigram model from a %v model", t.Type)
+	}
+	pieces := make([]string, t.Vocab.Size())
+	for id := range pieces {
+		pieces[id], _ = t.Vocab.Word(id)
+	}
+	specials := t.specials()
+	for _, a := range t.AddedTokens {
+		if !a.Special && t.addedOnly[a.Content] {
+			specials = append(specials, a.Content)
+		}
+	}
+	opts := []UnigramOpt{WithUnigramSpecials(specials...)}
+	if t.UnkToken != "" {
+		opts = append(opts, WithUnigramUnknown(t.UnkToken))
+	}
+	return NewUnigram(pieces, t.Scores, opts...)
+}
+
+func (t *TokenizerJSON) specials() []string {
+	var retVal []string
+	for _, a := range t.AddedTokens {
+		if a.Special {
+			retVal = append(retVal, a.Content)
+		}
+	}
+	return retVal
+}
+
+// orderedVocab marshals a vocabulary as a JSON object whose keys are in order of ID.
+type orderedVocab struct {
+	words []string
+	ids   []int
+}
+
+func (v orderedVocab) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, w := range v.words {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalNoEscape(w)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(v.ids[i]))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalNoEscape marshals a string without escaping <, > and &, which are common in tokens.
+func marshalNoEscape(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// scoresFromFreqs returns the log of the relative frequency of every word. Words that have never been seen are scored as if they had been seen once.
+func scoresFromFreqs(v *Corpus) []float64 {
+	total := math.Max(float64(v.TotalFreq()), 1)
+	retVal := make([]float64, v.Size())
+	for id := range retVal {
+		retVal[id] = math.Log(math.Max(float64(v.IDFreq(id)), 1) / total)
+	}
+	return retVal
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// nullable turns a JSON null into nil.
+func nullable(raw json.RawMessage) json.RawMessage {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+	return raw
+}
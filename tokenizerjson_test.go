@@ -0,0 +1,222 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTokenizerJSON(t *testing.T, filename string) *TokenizerJSON {
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	defer f.Close()
+	tj, err := LoadTokenizerJSON(f)
+	require.NoError(t, err)
+	return tj
+}
+
+// genericJSON decodes JSON into maps and slices, so that documents can be compared regardless of formatting and key order.
+func genericJSON(t *testing.T, data []byte) map[string]interface{} {
+	var retVal map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &retVal))
+	return retVal
+}
+
+func TestLoadTokenizerJSON_WordPiece(t *testing.T) {
+	assert := assert.New(t)
+	tj := loadTokenizerJSON(t, "testdata/hf/wordpiece.json")
+
+	assert.Equal("WordPiece", tj.Type)
+	assert.Equal(15, tj.Vocab.Size())
+	id, _ := tj.Vocab.Id("##ing")
+	assert.Equal(11, id)
+	assert.Equal("[UNK]", tj.UnkToken)
+	assert.Equal("##", tj.ContinuingSubwordPrefix)
+	assert.Equal(100, tj.MaxInputCharsPerWord)
+	assert.Len(tj.AddedTokens, 5)
+	assert.Equal(AddedToken{ID: 2, Content: "[CLS]", Special: true}, tj.AddedTokens[2])
+	assert.Equal(NormalizerSettings{Lowercase: true, StripAccents: true, CleanText: true, HandleChineseChars: true}, tj.Normalizer)
+
+	wp, err := tj.WordPiece()
+	require.NoError(t, err)
+	assert.Equal([]string{"[CLS]", "un", "##want", "##ed", ",", "runn", "##ing", "[SEP]"}, wp.Tokenize("[CLS] UNwantéd,running [SEP]"))
+	assert.Equal([]int{9, 6, 7, 12, 10, 11}, wp.Encode("UNwantéd,running"))
+
+	_, err = tj.Unigram()
+	assert.Error(err)
+}
+
+func TestLoadTokenizerJSON_BPE(t *testing.T) {
+	assert := assert.New(t)
+	tj := loadTokenizerJSON(t, "testdata/hf/bpe.json")
+
+	assert.Equal("BPE", tj.Type)
+	assert.Equal(15, tj.Vocab.Size())
+	id, _ := tj.Vocab.Id("<custom>")
+	assert.Equal(14, id)
+	assert.Equal("", tj.UnkToken)
+	assert.Equal([]Merge{{"h", "e"}, {"l", "l"}, {"Ġ", "w"}, {"he", "ll"}, {"hell", "o"}}, tj.Merges)
+	assert.Equal(NormalizerSettings{}, tj.Normalizer)
+	assert.JSONEq(`{"type":"ByteLevel","add_prefix_space":false,"trim_offsets":true,"use_regex":true}`, string(tj.PreTokenizer))
+
+	b, err := tj.ByteLevelBPE()
+	require.NoError(t, err)
+	assert.Equal([]string{"hello", "Ġw", "o", "r", "l", "d", "<|endoftext|>"}, b.Tokenize("hello world<|endoftext|>"))
+	assert.Equal([]int{12, 10, 5, 6, 4, 1, 13}, b.Encode("hello world<|endoftext|>"))
+
+	_, err = tj.WordPiece()
+	assert.Error(err)
+}
+
+func TestLoadTokenizerJSON_Unigram(t *testing.T) {
+	assert := assert.New(t)
+	tj := loadTokenizerJSON(t, "testdata/hf/unigram.json")
+
+	assert.Equal("Unigram", tj.Type)
+	assert.Equal(14, tj.Vocab.Size())
+	assert.Equal("<unk>", tj.UnkToken)
+	assert.Equal(-3.1, tj.Scores[3])
+	assert.Equal(NormalizerSettings{Lowercase: true, Unicode: "NFKC"}, tj.Normalizer)
+
+	u, err := tj.Unigram()
+	require.NoError(t, err)
+	assert.Equal([]string{"▁walk", "ing"}, u.Encode("walking"))
+	assert.Equal([]int{3, 5}, u.EncodeIDs("walked"))
+	assert.Equal([]int{6, 0}, u.EncodeIDs("talkz"))
+	assert.Equal([]string{"</s>"}, u.Encode("</s>"))
+}
+
+func TestLoadTokenizerJSON_UnigramAddedTokens(t *testing.T) {
+	assert := assert.New(t)
+	data, err := os.ReadFile("testdata/hf/unigram.json")
+	require.NoError(t, err)
+	doc := genericJSON(t, data)
+	doc["added_tokens"] = append(doc["added_tokens"].([]interface{}), map[string]interface{}{"id": 14, "content": "▁walking", "special": false})
+	data, err = json.Marshal(doc)
+	require.NoError(t, err)
+	tj, err := LoadTokenizerJSON(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// an added token the model doesn't know has no score, so it doesn't beat the pieces of the model
+	u, err := tj.Unigram()
+	require.NoError(t, err)
+	assert.Equal([]string{"▁walk", "ing"}, u.Encode("walking"))
+	id, ok := u.Vocab().Id("▁walking")
+	assert.True(ok)
+	assert.Equal(14, id)
+}
+
+func TestTokenizerJSON_RoundTrip(t *testing.T) {
+	for _, name := range []string{"wordpiece", "bpe", "unigram"} {
+		t.Run(name, func(t *testing.T) {
+			filename := "testdata/hf/" + name + ".json"
+			original, err := os.ReadFile(filename)
+			require.NoError(t, err)
+			tj := loadTokenizerJSON(t, filename)
+
+			var buf bytes.Buffer
+			require.NoError(t, tj.Save(&buf))
+			want, got := genericJSON(t, original), genericJSON(t, buf.Bytes())
+
+			// merges are always written as strings, which every version of the library reads
+			if merges, ok := want["model"].(map[string]interface{})["merges"].([]interface{}); ok {
+				for i, m := range merges {
+					pair := m.([]interface{})
+					merges[i] = pair[0].(string) + " " + pair[1].(string)
+				}
+			}
+			assert.Equal(t, want, got)
+
+			reloaded, err := LoadTokenizerJSON(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, tj.Vocab.words, reloaded.Vocab.words)
+			assert.Equal(t, tj.Merges, reloaded.Merges)
+			assert.Equal(t, tj.Scores, reloaded.Scores)
+		})
+	}
+}
+
+func TestTokenizerJSON_Save(t *testing.T) {
+	assert := assert.New(t)
+	vocab := loadBertVocab(t)
+	tj := &TokenizerJSON{
+		Type:                    "WordPiece",
+		Vocab:                   vocab,
+		UnkToken:                "[UNK]",
+		ContinuingSubwordPrefix: "##",
+		AddedTokens:             []AddedToken{{ID: 0, Content: "[UNK]", Special: true}},
+		Normalizer:              NormalizerSettings{Lowercase: true, StripAccents: true, CleanText: true, HandleChineseChars: true},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, tj.Save(&buf))
+	doc := genericJSON(t, buf.Bytes())
+	assert.Equal(map[string]interface{}{"type": "BertPreTokenizer"}, doc["pre_tokenizer"])
+	assert.Equal(map[string]interface{}{"type": "BertNormalizer", "clean_text": true, "handle_chinese_chars": true, "strip_accents": true, "lowercase": true}, doc["normalizer"])
+	model := doc["model"].(map[string]interface{})
+	assert.Equal(100.0, model["max_input_chars_per_word"])
+	assert.Len(model["vocab"], 13)
+	// the vocabulary is written in order of ID
+	assert.True(strings.Index(buf.String(), `"[SEP]": 2`) < strings.Index(buf.String(), `"want": 3`))
+	assert.True(strings.Index(buf.String(), `"want": 3`) < strings.Index(buf.String(), `"##want": 4`))
+
+	reloaded, err := LoadTokenizerJSON(&buf)
+	require.NoError(t, err)
+	assert.Equal(vocab.words, reloaded.Vocab.words)
+
+	// a Unigram model without scores is scored by frequency
+	c := New()
+	c.AddN("walk", 3)
+	tj = &TokenizerJSON{Type: "Unigram", Vocab: c, UnkToken: "-UNKNOWN-", Normalizer: NormalizerSettings{Lowercase: true, StripAccents: true}}
+	buf.Reset()
+	require.NoError(t, tj.Save(&buf))
+	doc = genericJSON(t, buf.Bytes())
+	assert.Equal(map[string]interface{}{"type": "Sequence", "normalizers": []interface{}{
+		map[string]interface{}{"type": "NFD"},
+		map[string]interface{}{"type": "StripAccents"},
+		map[string]interface{}{"type": "Lowercase"},
+	}}, doc["normalizer"])
+	model = doc["model"].(map[string]interface{})
+	assert.Equal(1.0, model["unk_id"])
+	assert.Equal([]interface{}{"walk", math.Log(0.5)}, model["vocab"].([]interface{})[3])
+}
+
+func TestLoadTokenizerJSON_Errors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := LoadTokenizerJSON(strings.NewReader(`{"model": {"type": "WordLevel", "vocab": {}}}`))
+	assert.Error(err)
+	_, err = LoadTokenizerJSON(strings.NewReader(`{"added_tokens": [{"id": 0, "content": "b"}], "model": {"type": "BPE", "vocab": {"a": 0, "b": 1}, "merges": ["a b"]}}`))
+	assert.Error(err)
+	_, err = LoadTokenizerJSON(strings.NewReader(`{"model": {"type": "WordPiece", "vocab": {"a": 0, "b": 2}}}`))
+	assert.Error(err)
+
+	// merges as strings, and no model type
+	tj, err := LoadTokenizerJSON(strings.NewReader(`{"model": {"vocab": {"a": 0, "b": 1, "ab": 2}, "merges": ["a b"]}}`))
+	require.NoError(t, err)
+	assert.Equal("BPE", tj.Type)
+	assert.Equal([]Merge{{"a", "b"}}, tj.Merges)
+}
+
+func TestTokenizerJSON_WordPieceNormalizer(t *testing.T) {
+	assert := assert.New(t)
+	doc := `{"normalizer": {"type": "Sequence", "normalizers": [
+		{"type": "NFKC"},
+		{"type": "BertNormalizer", "clean_text": true, "handle_chinese_chars": true, "strip_accents": false, "lowercase": true}
+	]}, "model": {"type": "WordPiece", "unk_token": "[UNK]", "continuing_subword_prefix": "##", "vocab": {"[UNK]": 0, "héllo": 1, "fine": 2}}}`
+	tj, err := LoadTokenizerJSON(strings.NewReader(doc))
+	require.NoError(t, err)
+	assert.Equal(NormalizerSettings{Lowercase: true, CleanText: true, HandleChineseChars: true, Unicode: "NFKC"}, tj.Normalizer)
+
+	wp, err := tj.WordPiece()
+	require.NoError(t, err)
+	assert.Equal([]int{1, 2}, wp.Encode("Héllo ﬁne"))
+
+	tj.Normalizer.Unicode = "NFX"
+	_, err = tj.WordPiece()
+	assert.Error(err)
+}
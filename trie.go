@@ -0,0 +1,186 @@
+package corpus
+
+import (
+	"container/heap"
+	"sort"
+	"unicode/utf8"
+)
+
+// Trie is a prefix tree over the words of a Vocabulary. It supports exact lookups, prefix enumeration, frequency ranked
+// prefix search (i.e. autocomplete) and longest prefix matching.
+//
+// The trie holds IDs, and asks the Vocabulary it was built over for frequencies, so it stays accurate as frequencies change.
+// The empty word (the NULL entry of a Corpus) is never stored.
+type Trie struct {
+	root  trieNode
+	v     Vocabulary
+	count int
+}
+
+type trieNode struct {
+	r        rune
+	id       int // -1 if no word ends here
+	children []*trieNode
+}
+
+// NewTrie creates a Trie holding all the words of the given Vocabulary.
+func NewTrie(v Vocabulary) *Trie {
+	t := &Trie{v: v}
+	t.root.id = -1
+	for id := 0; id < v.Size(); id++ {
+		w, _ := v.Word(id)
+		t.Insert(w, id)
+	}
+	return t
+}
+
+// Insert adds a word with the given ID to the trie. If the word is already in the trie, its ID is replaced.
+func (t *Trie) Insert(word string, id int) {
+	if word == "" {
+		return
+	}
+	n := &t.root
+	for _, r := range word {
+		n = n.child(r, true)
+	}
+	if n.id < 0 {
+		t.count++
+	}
+	n.id = id
+}
+
+// Len returns the number of words in the trie.
+func (t *Trie) Len() int { return t.count }
+
+// Lookup returns the ID of the word, and whether or not it's in the trie.
+func (t *Trie) Lookup(word string) (int, bool) {
+	if word == "" {
+		return 0, false
+	}
+	n := t.find(word)
+	if n == nil || n.id < 0 {
+		return 0, false
+	}
+	return n.id, true
+}
+
+// WithPrefix returns all the words that start with the given prefix, in lexicographic order.
+func (t *Trie) WithPrefix(prefix string) []string {
+	var retVal []string
+	t.walk(prefix, func(word string, id int) {
+		retVal = append(retVal, word)
+	})
+	return retVal
+}
+
+// PrefixSearch returns up to k words that start with the given prefix, ranked by frequency (most frequent first).
+// Ties are broken lexicographically. If k <= 0, all matching words are returned.
+func (t *Trie) PrefixSearch(prefix string, k int) []string {
+	var h wordFreqHeap
+	t.walk(prefix, func(word string, id int) {
+		wf := wordFreq{word, t.v.WordFreq(word)}
+		if k <= 0 || h.Len() < k {
+			heap.Push(&h, wf)
+			return
+		}
+		if h.less(h[0], wf) {
+			h[0] = wf
+			heap.Fix(&h, 0)
+		}
+	})
+
+	// h is a min heap, so popping yields the words from least to most frequent
+	retVal := make([]string, h.Len())
+	for i := len(retVal) - 1; i >= 0; i-- {
+		retVal[i] = heap.Pop(&h).(wordFreq).word
+	}
+	return retVal
+}
+
+// LongestPrefix returns the longest word in the trie that is a prefix of s, along with its ID.
+// If no word in the trie is a prefix of s, it returns false.
+func (t *Trie) LongestPrefix(s string) (word string, id int, ok bool) {
+	n := &t.root
+	for i, r := range s {
+		if n = n.child(r, false); n == nil {
+			break
+		}
+		if n.id >= 0 {
+			word, id, ok = s[:i+utf8.RuneLen(r)], n.id, true
+		}
+	}
+	return
+}
+
+// find returns the node reached by following the prefix, or nil if there is no such node.
+func (t *Trie) find(prefix string) *trieNode {
+	n := &t.root
+	for _, r := range prefix {
+		if n = n.child(r, false); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// walk calls fn on every word that starts with the prefix, in lexicographic order.
+func (t *Trie) walk(prefix string, fn func(word string, id int)) {
+	n := t.find(prefix)
+	if n == nil {
+		return
+	}
+	n.walk([]byte(prefix), fn)
+}
+
+func (n *trieNode) walk(prefix []byte, fn func(word string, id int)) {
+	if n.id >= 0 && len(prefix) > 0 {
+		fn(string(prefix), n.id)
+	}
+	for _, c := range n.children {
+		c.walk(utf8.AppendRune(prefix, c.r), fn)
+	}
+}
+
+// child returns the child node for the rune. If create is true, the child is created if it doesn't exist.
+func (n *trieNode) child(r rune, create bool) *trieNode {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].r >= r })
+	if i < len(n.children) && n.children[i].r == r {
+		return n.children[i]
+	}
+	if !create {
+		return nil
+	}
+	c := &trieNode{r: r, id: -1}
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = c
+	return c
+}
+
+type wordFreq struct {
+	word string
+	freq int
+}
+
+// wordFreqHeap is a min heap of words, ordered by frequency and then reverse lexicographically.
+// The root is therefore the word that ranks lowest in a PrefixSearch.
+type wordFreqHeap []wordFreq
+
+func (h wordFreqHeap) less(a, b wordFreq) bool {
+	if a.freq != b.freq {
+		return a.freq < b.freq
+	}
+	return a.word > b.word
+}
+
+func (h wordFreqHeap) Len() int            { return len(h) }
+func (h wordFreqHeap) Less(i, j int) bool  { return h.less(h[i], h[j]) }
+func (h wordFreqHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *wordFreqHeap) Push(x interface{}) { *h = append(*h, x.(wordFreq)) }
+func (h *wordFreqHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,68 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrie(t *testing.T) {
+	assert := assert.New(t)
+	c, err := Construct(WithWords([]string{"car", "cart", "cart", "carton", "cat", "cat", "cat", "dog", "café"}), WithTrie())
+	require.NoError(t, err)
+	trie := c.Trie()
+	require.NotNil(t, trie)
+	assert.Equal(6, trie.Len())
+
+	id, ok := trie.Lookup("cart")
+	assert.True(ok)
+	cartID, _ := c.Id("cart")
+	assert.Equal(cartID, id)
+	_, ok = trie.Lookup("ca")
+	assert.False(ok)
+	_, ok = trie.Lookup("")
+	assert.False(ok)
+
+	assert.Equal([]string{"café", "car", "cart", "carton", "cat"}, trie.WithPrefix("ca"))
+	assert.Equal([]string{"car", "cart", "carton"}, trie.WithPrefix("car"))
+	assert.Nil(trie.WithPrefix("x"))
+
+	assert.Equal([]string{"cat", "cart"}, trie.PrefixSearch("ca", 2))
+	assert.Equal([]string{"cat", "cart", "café", "car", "carton"}, trie.PrefixSearch("ca", 0))
+
+	word, _, ok := trie.LongestPrefix("cartons")
+	assert.True(ok)
+	assert.Equal("carton", word)
+	word, _, ok = trie.LongestPrefix("cafés")
+	assert.True(ok)
+	assert.Equal("café", word)
+	_, _, ok = trie.LongestPrefix("ca")
+	assert.False(ok)
+
+	// stays in sync with Add
+	c.Add("caterpillar")
+	c.Add("carton")
+	c.Add("carton")
+	assert.Equal([]string{"carton", "cat"}, trie.PrefixSearch("ca", 2))
+	id, ok = trie.Lookup("caterpillar")
+	assert.True(ok)
+	assert.Equal(c.Size()-1, id)
+}
+
+func TestTrie_Gob(t *testing.T) {
+	c := New()
+	c.BuildTrie()
+	c.Add("hello")
+	c.Add("help")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, gob.NewEncoder(buf).Encode(c))
+
+	c2 := New()
+	c2.BuildTrie()
+	require.NoError(t, gob.NewDecoder(buf).Decode(c2))
+	assert.Equal(t, []string{"hello", "help"}, c2.Trie().WithPrefix("hel"))
+}
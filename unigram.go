@@ -0,0 +1,526 @@
+package corpus
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// unknownPenalty is how much less likely than the least likely piece an unknown rune is, in nats. It is the same as SentencePiece's.
+const unknownPenalty = 10
+
+// UnigramOpt is an option for TrainUnigram and NewUnigram.
+type UnigramOpt func(u *Unigram)
+
+// WithWordStartMarker sets the marker that is prepended to every word before it is split, so that pieces which start words are told apart from
+// the others. The default is "▁" (U+2581), as in SentencePiece. An empty marker turns this off.
+func WithWordStartMarker(marker string) UnigramOpt {
+	return func(u *Unigram) { u.marker = marker }
+}
+
+// WithUnigramUnknown sets the piece that unknown runes are encoded as. The default is "<unk>". It is added to the special pieces.
+func WithUnigramUnknown(token string) UnigramOpt {
+	return func(u *Unigram) { u.unknown = token }
+}
+
+// WithUnigramSpecials adds pieces that are never split, such as "<s>" and "</s>". When training, they are given the first IDs, after the unknown piece.
+func WithUnigramSpecials(tokens ...string) UnigramOpt {
+	return func(u *Unigram) { u.specials = append(u.specials, tokens...) }
+}
+
+// WithMaxPieceLength sets the length (in runes) of the longest piece. The default is 16.
+func WithMaxPieceLength(n int) UnigramOpt {
+	return func(u *Unigram) { u.maxLen = n }
+}
+
+// WithSeedSize sets how many of the most frequent substrings of the training words make up the seed vocabulary, besides the single runes. The default is 100000.
+func WithSeedSize(n int) UnigramOpt {
+	return func(u *Unigram) { u.seedSize = n }
+}
+
+// WithShrinkFactor sets the fraction of the pieces kept by each round of pruning. The default is 0.75.
+func WithShrinkFactor(f float64) UnigramOpt {
+	return func(u *Unigram) { u.shrink = f }
+}
+
+// WithEMIterations sets the number of EM iterations run before each round of pruning. The default is 2.
+func WithEMIterations(n int) UnigramOpt {
+	return func(u *Unigram) { u.iterations = n }
+}
+
+// Unigram is a unigram language model over subwords ("pieces"), as in SentencePiece (Kudo, 2018). A word is split into the sequence of pieces
+// with the highest product of probabilities, or, for subword regularization, into a sequence of pieces sampled from all its segmentations.
+type Unigram struct {
+	vocab   *Corpus
+	scores  []float64 // the log probability of every piece, by ID. Special pieces have a score of 0.
+	marker  string
+	unknown string
+	unkLP   float64
+
+	specials []string
+	maxLen   int
+
+	// training
+	seedSize   int
+	shrink     float64
+	iterations int
+}
+
+func newUnigram(opts ...UnigramOpt) *Unigram {
+	u := &Unigram{
+		marker:     "▁",
+		unknown:    "<unk>",
+		maxLen:     16,
+		seedSize:   100000,
+		shrink:     0.75,
+		iterations: 2,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	specials := []string{u.unknown}
+	for _, s := range u.specials {
+		if s != u.unknown {
+			specials = append(specials, s)
+		}
+	}
+	u.specials = specials
+	return u
+}
+
+// NewUnigram creates a unigram model from pieces and their log probabilities, such as one trained by SentencePiece. The ID of each piece is its index.
+// The unknown piece is added at the end if it isn't one of the pieces.
+func NewUnigram(pieces []string, scores []float64, opts ...UnigramOpt) (*Unigram, error) {
+	if len(pieces) != len(scores) {
+		return nil, errors.Errorf("Expected as many scores as pieces (%d). Got %d instead", len(pieces), len(scores))
+	}
+	u := newUnigram(opts...)
+	u.maxLen = 0
+
+	vocab, err := Construct(WithSize(len(pieces) + 1))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pieces {
+		vocab.AddN(p, 0)
+		if l := utf8.RuneCountInString(p); l > u.maxLen {
+			u.maxLen = l
+		}
+	}
+	u.scores = append([]float64(nil), scores...)
+	if _, ok := vocab.Id(u.unknown); !ok {
+		vocab.AddN(u.unknown, 0)
+		u.scores = append(u.scores, 0)
+	}
+	u.vocab = vocab
+	u.setUnknownScore()
+	return u, nil
+}
+
+// TrainUnigram learns a unigram model of at most vocabSize pieces (including the special pieces) from the words and frequencies of a vocabulary, such as a *Corpus.
+// The special words of the vocabulary are not part of the training data.
+//
+// Training starts with a large seed vocabulary of all the runes and the most frequent substrings of the words, and repeatedly re-estimates the
+// probabilities of the pieces with EM, and then drops the pieces whose removal would least reduce the likelihood of the corpus, until vocabSize is reached.
+// Single runes are never dropped, but EM drops pieces that are expected to occur less than half a time. The frequency of each piece in the returned model's vocabulary is its expected count in the corpus, rounded.
+func TrainUnigram(c Vocabulary, vocabSize int, opts ...UnigramOpt) (*Unigram, error) {
+	u := newUnigram(opts...)
+	if u.maxLen < 1 {
+		return nil, errors.Errorf("Cannot train a unigram model with a maximum piece length of %d", u.maxLen)
+	}
+	if u.shrink <= 0 || u.shrink >= 1 {
+		return nil, errors.Errorf("Expected a shrink factor between 0 and 1. Got %v instead", u.shrink)
+	}
+
+	t := &unigramTrainer{u: u}
+	for id := 0; id < c.Size(); id++ {
+		w, _ := c.Word(id)
+		freq := c.WordFreq(w)
+		if isSpecialIn(c, w) || freq < 1 {
+			continue
+		}
+		t.words = append(t.words, u.marker+w)
+		t.freqs = append(t.freqs, float64(freq))
+	}
+	t.seed()
+
+	target := vocabSize - len(u.specials)
+	if target < t.runes {
+		return nil, errors.Errorf("Cannot train a unigram model of %d pieces. There are already %d special pieces and %d runes", vocabSize, len(u.specials), t.runes)
+	}
+	for {
+		for i := 0; i < u.iterations; i++ {
+			t.step()
+		}
+		if len(t.logProbs) <= target {
+			break
+		}
+		t.prune(target)
+	}
+	counts := t.step()
+
+	// the pieces are ordered by probability, so that the most likely pieces have the smallest IDs
+	pieces := make([]string, 0, len(t.logProbs))
+	for p := range t.logProbs {
+		pieces = append(pieces, p)
+	}
+	sort.Slice(pieces, func(i, j int) bool {
+		if t.logProbs[pieces[i]] != t.logProbs[pieces[j]] {
+			return t.logProbs[pieces[i]] > t.logProbs[pieces[j]]
+		}
+		return pieces[i] < pieces[j]
+	})
+
+	vocab, err := Construct(WithSize(len(u.specials) + len(pieces)))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range u.specials {
+		vocab.AddN(s, 0)
+		u.scores = append(u.scores, 0)
+	}
+	for _, p := range pieces {
+		vocab.AddN(p, int(math.Round(counts[p])))
+		u.scores = append(u.scores, t.logProbs[p])
+	}
+	u.vocab = vocab
+	u.setUnknownScore()
+	return u, nil
+}
+
+// Vocab returns the vocabulary of pieces.
+func (u *Unigram) Vocab() *Corpus { return u.vocab }
+
+// Score returns the log probability of the piece with the given ID.
+func (u *Unigram) Score(id int) float64 {
+	if id < 0 || id >= len(u.scores) {
+		return math.Inf(-1)
+	}
+	return u.scores[id]
+}
+
+// WordStartMarker returns the marker prepended to words.
+func (u *Unigram) WordStartMarker() string { return u.marker }
+
+// Encode splits a word into its most probable sequence of pieces. Runes that are not pieces are kept as they are; EncodeIDs turns them into the unknown piece.
+// Special pieces are returned as they are.
+func (u *Unigram) Encode(word string) []string {
+	if u.isSpecial(word) {
+		return []string{word}
+	}
+	return viterbiPieces(u.marker+word, u.maxLen, u.logProb)
+}
+
+// EncodeIDs splits a word into its most probable sequence of pieces, and returns their IDs.
+func (u *Unigram) EncodeIDs(word string) []int {
+	return u.ids(u.Encode(word))
+}
+
+// EncodeTokens splits every token into pieces, which keep the offsets of the parts of the token they cover. See Token.
+// The word start marker is not part of the text, so a piece that is only the marker has an empty span.
+func (u *Unigram) EncodeTokens(tokens []Token) []Token {
+	retVal := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		pieces := u.Encode(t.Text)
+		skip := len(u.marker)
+		if u.isSpecial(t.Text) {
+			skip = 0
+		}
+		retVal = append(retVal, concatTokens(t, pieces, u.ids(pieces), skip)...)
+	}
+	return retVal
+}
+
+// Sample splits a word into a sequence of pieces drawn from the distribution over all its segmentations, with every piece's probability raised to the power alpha.
+// Smaller alphas give more varied segmentations; as alpha grows, samples approach the segmentation Encode returns. This is the subword regularization of Kudo (2018).
+func (u *Unigram) Sample(word string, alpha float64, rng *rand.Rand) []string {
+	if u.isSpecial(word) {
+		return []string{word}
+	}
+	text := u.marker + word
+	offsets := runeOffsets(text)
+	n := len(offsets) - 1
+	if n == 0 {
+		return []string{}
+	}
+
+	// forward filtering
+	alphas := make([]float64, n+1)
+	for i := 1; i <= n; i++ {
+		alphas[i] = math.Inf(-1)
+		for j := i - 1; j >= 0 && i-j <= u.maxLen; j-- {
+			if lp, ok := u.logProb(text[offsets[j]:offsets[i]]); ok {
+				alphas[i] = logAddExp(alphas[i], alphas[j]+alpha*lp)
+			}
+		}
+	}
+
+	// backward sampling
+	var retVal []string
+	weights := make([]float64, 0, u.maxLen)
+	starts := make([]int, 0, u.maxLen)
+	for i := n; i > 0; {
+		weights, starts = weights[:0], starts[:0]
+		for j := i - 1; j >= 0 && i-j <= u.maxLen; j-- {
+			if lp, ok := u.logProb(text[offsets[j]:offsets[i]]); ok {
+				weights = append(weights, math.Exp(alphas[j]+alpha*lp-alphas[i]))
+				starts = append(starts, j)
+			}
+		}
+		j := starts[len(starts)-1]
+		x := rng.Float64()
+		for k, w := range weights {
+			if x -= w; x < 0 {
+				j = starts[k]
+				break
+			}
+		}
+		retVal = append(retVal, text[offsets[j]:offsets[i]])
+		i = j
+	}
+
+	// reverse it
+	for i, j := 0, len(retVal)-1; i < j; i, j = i+1, j-1 {
+		retVal[i], retVal[j] = retVal[j], retVal[i]
+	}
+	return retVal
+}
+
+// SampleIDs is like Sample, but returns the IDs of the pieces.
+func (u *Unigram) SampleIDs(word string, alpha float64, rng *rand.Rand) []int {
+	return u.ids(u.Sample(word, alpha, rng))
+}
+
+// Decode joins pieces back into text. Word start markers become spaces.
+func (u *Unigram) Decode(pieces []string) string {
+	s := strings.Join(pieces, "")
+	if u.marker != "" {
+		s = strings.TrimLeft(strings.ReplaceAll(s, u.marker, " "), " ")
+	}
+	return s
+}
+
+func (u *Unigram) ids(pieces []string) []int {
+	unk, _ := u.vocab.Id(u.unknown)
+	retVal := make([]int, len(pieces))
+	for i, p := range pieces {
+		id, ok := u.vocab.Id(p)
+		if !ok {
+			id = unk
+		}
+		retVal[i] = id
+	}
+	return retVal
+}
+
+// logProb is the log probability of a piece. Special pieces cannot be part of a word, and unknown runes get the unknown score.
+func (u *Unigram) logProb(piece string) (float64, bool) {
+	if id, ok := u.vocab.Id(piece); ok && !u.isSpecial(piece) {
+		return u.scores[id], true
+	}
+	if utf8.RuneCountInString(piece) == 1 {
+		return u.unkLP, true
+	}
+	return 0, false
+}
+
+func (u *Unigram) isSpecial(piece string) bool {
+	for _, s := range u.specials {
+		if s == piece {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *Unigram) setUnknownScore() {
+	min := 0.0
+	for id, s := range u.scores {
+		w, _ := u.vocab.Word(id)
+		if !u.isSpecial(w) && s < min {
+			min = s
+		}
+	}
+	u.unkLP = min - unknownPenalty
+}
+
+// viterbiPieces splits the text into its most probable sequence of pieces of at most maxLen runes.
+// Pieces for which logProb returns false are not considered. If the text cannot be split, nil is returned.
+func viterbiPieces(text string, maxLen int, logProb func(piece string) (float64, bool)) []string {
+	offsets := runeOffsets(text)
+	n := len(offsets) - 1
+	best := make([]float64, n+1)
+	from := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i], from[i] = math.Inf(-1), -1
+		for j := i - 1; j >= 0 && i-j <= maxLen; j-- {
+			if math.IsInf(best[j], -1) {
+				continue
+			}
+			if lp, ok := logProb(text[offsets[j]:offsets[i]]); ok && best[j]+lp > best[i] {
+				best[i], from[i] = best[j]+lp, j
+			}
+		}
+	}
+	if n > 0 && from[n] < 0 {
+		return nil
+	}
+
+	retVal := make([]string, 0)
+	for i := n; i > 0; i = from[i] {
+		retVal = append(retVal, text[offsets[from[i]]:offsets[i]])
+	}
+
+	// reverse it
+	for i, j := 0, len(retVal)-1; i < j; i, j = i+1, j-1 {
+		retVal[i], retVal[j] = retVal[j], retVal[i]
+	}
+	return retVal
+}
+
+// unigramTrainer holds the state of TrainUnigram.
+type unigramTrainer struct {
+	u     *Unigram
+	words []string
+	freqs []float64
+
+	logProbs map[string]float64
+	runes    int // the number of single rune pieces, which are never pruned
+}
+
+func (t *unigramTrainer) logProb(piece string) (float64, bool) {
+	lp, ok := t.logProbs[piece]
+	return lp, ok
+}
+
+// seed builds the seed vocabulary: every rune, and the most frequent substrings of up to maxLen runes, where each substring is weighted by its length.
+func (t *unigramTrainer) seed() {
+	counts := make(map[string]float64)
+	for i, w := range t.words {
+		offsets := runeOffsets(w)
+		for j := 0; j < len(offsets)-1; j++ {
+			for k := j + 1; k < len(offsets) && k-j <= t.u.maxLen; k++ {
+				counts[w[offsets[j]:offsets[k]]] += t.freqs[i]
+			}
+		}
+	}
+
+	var candidates []string
+	seeds := make(map[string]float64)
+	for s, count := range counts {
+		if utf8.RuneCountInString(s) == 1 {
+			seeds[s] = count
+			continue
+		}
+		if count < 2 {
+			continue
+		}
+		candidates = append(candidates, s)
+	}
+	t.runes = len(seeds)
+
+	score := func(s string) float64 { return counts[s] * float64(utf8.RuneCountInString(s)) }
+	sort.Slice(candidates, func(i, j int) bool {
+		if si, sj := score(candidates[i]), score(candidates[j]); si != sj {
+			return si > sj
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > t.u.seedSize {
+		candidates = candidates[:t.u.seedSize]
+	}
+	for _, s := range candidates {
+		seeds[s] = counts[s]
+	}
+	t.logProbs = normalizeCounts(seeds)
+}
+
+// step is one iteration of EM. It returns the expected counts of the pieces under the old model.
+func (t *unigramTrainer) step() map[string]float64 {
+	counts := make(map[string]float64, len(t.logProbs))
+	for i, w := range t.words {
+		forwardBackward(w, t.freqs[i], t.u.maxLen, t.logProb, counts)
+	}
+
+	// pieces that are expected to occur less than half a time are dropped, but runes are always kept
+	for p := range t.logProbs {
+		if counts[p] < 0.5 && utf8.RuneCountInString(p) > 1 {
+			delete(counts, p)
+			continue
+		}
+		if _, ok := counts[p]; !ok {
+			counts[p] = 0
+		}
+	}
+	t.logProbs = normalizeCounts(counts)
+	return counts
+}
+
+// prune drops the pieces whose removal costs the least likelihood, keeping the larger of target and the shrink factor times the current number of pieces.
+//
+// The cost of removing a piece is approximated the way SentencePiece does it: every occurrence of the piece in the most probable segmentations of the
+// words is replaced by the most probable segmentation of the piece without it.
+func (t *unigramTrainer) prune(target int) {
+	freqs := make(map[string]float64, len(t.logProbs))
+	for i, w := range t.words {
+		for _, p := range viterbiPieces(w, t.u.maxLen, t.logProb) {
+			freqs[p] += t.freqs[i]
+		}
+	}
+
+	type candidate struct {
+		piece string
+		loss  float64
+	}
+	var candidates []candidate
+	for p, lp := range t.logProbs {
+		if utf8.RuneCountInString(p) == 1 {
+			continue
+		}
+		without := func(piece string) (float64, bool) {
+			if piece == p {
+				return 0, false
+			}
+			return t.logProb(piece)
+		}
+		var alt float64
+		for _, q := range viterbiPieces(p, t.u.maxLen, without) {
+			alt += t.logProbs[q]
+		}
+		candidates = append(candidates, candidate{p, freqs[p] * (lp - alt)})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].loss != candidates[j].loss {
+			return candidates[i].loss > candidates[j].loss
+		}
+		return candidates[i].piece < candidates[j].piece
+	})
+
+	keep := int(float64(len(t.logProbs)) * t.u.shrink)
+	if keep < target {
+		keep = target
+	}
+	for _, c := range candidates[keep-t.runes:] {
+		delete(t.logProbs, c.piece)
+	}
+}
+
+// normalizeCounts turns counts into log probabilities. Tiny counts are raised to a floor, so that every rune stays usable.
+func normalizeCounts(counts map[string]float64) map[string]float64 {
+	const floor = 1e-6
+	var total float64
+	for _, count := range counts {
+		total += math.Max(count, floor)
+	}
+	retVal := make(map[string]float64, len(counts))
+	for p, count := range counts {
+		count = math.Max(count, floor)
+		retVal[p] = math.Log(count / total)
+	}
+	return retVal
+}
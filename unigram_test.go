@@ -0,0 +1,147 @@
+package corpus
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// morphology returns a corpus of words made of a few stems and suffixes.
+func morphology() *Corpus {
+	c := New()
+	stems := []string{"walk", "talk", "jump", "play", "call", "kick"}
+	suffixes := []string{"", "s", "ed", "ing", "er", "ers"}
+	for i, stem := range stems {
+		for j, suffix := range suffixes {
+			c.AddN(stem+suffix, 1+(i*7+j*5)%11)
+		}
+	}
+	return c
+}
+
+func TestTrainUnigram(t *testing.T) {
+	assert := assert.New(t)
+	u, err := TrainUnigram(morphology(), 40, WithUnigramSpecials("<s>", "</s>"), WithMaxPieceLength(6))
+	require.NoError(t, err)
+
+	assert.True(u.Vocab().Size() <= 40)
+	assert.NoError(u.Vocab().Validate())
+	for i, s := range []string{"<unk>", "<s>", "</s>"} {
+		id, ok := u.Vocab().Id(s)
+		assert.True(ok)
+		assert.Equal(i, id)
+		assert.Equal(0.0, u.Score(id))
+	}
+
+	// pieces are ordered by probability, and every rune is a piece
+	for id := 4; id < u.Vocab().Size(); id++ {
+		assert.True(u.Score(id-1) >= u.Score(id), "%d", id)
+	}
+	for _, r := range "▁walktjumpyceingrds" {
+		_, ok := u.Vocab().Id(string(r))
+		assert.True(ok, "%c", r)
+	}
+	assert.True(math.IsInf(u.Score(u.Vocab().Size()), -1))
+
+	// stems and suffixes are learnt
+	assert.Equal([]string{"▁walk", "ing"}, u.Encode("walking"))
+	assert.Equal([]string{"▁jump", "ers"}, u.Encode("jumpers"))
+	assert.Equal("jumpers", u.Decode(u.Encode("jumpers")))
+
+	_, err = TrainUnigram(morphology(), 10)
+	assert.Error(err)
+	_, err = TrainUnigram(morphology(), 40, WithShrinkFactor(1))
+	assert.Error(err)
+}
+
+func TestTrainUnigram_Vocabulary(t *testing.T) {
+	// a vocabulary that isn't a *Corpus, with the same words, gives the same model
+	c := morphology()
+	v := newMapVocab()
+	for id := 0; id < c.Size(); id++ {
+		w, _ := c.Word(id)
+		if !c.IsSpecial(w) {
+			v.AddN(w, c.WordFreq(w))
+		}
+	}
+
+	want, err := TrainUnigram(c, 40, WithMaxPieceLength(6))
+	require.NoError(t, err)
+	u, err := TrainUnigram(v, 40, WithMaxPieceLength(6))
+	require.NoError(t, err)
+	for _, w := range []string{"walking", "jumpers", "talked"} {
+		assert.Equal(t, want.Encode(w), u.Encode(w))
+	}
+}
+
+func TestUnigram_Unknown(t *testing.T) {
+	assert := assert.New(t)
+	u, err := NewUnigram([]string{"<unk>", "▁", "a", "b", "▁ab", "x"}, []float64{0, -2, -1, -1, -1.5, -5})
+	require.NoError(t, err)
+
+	assert.Equal([]string{"▁ab", "z", "a"}, u.Encode("abza"))
+	assert.Equal([]int{4, 0, 2}, u.EncodeIDs("abza"))
+	assert.Equal([]string{"<unk>"}, u.Encode("<unk>"))
+	assert.Equal("abza", u.Decode(u.Encode("abza")))
+
+	_, err = NewUnigram([]string{"a"}, nil)
+	assert.Error(err)
+
+	// the unknown piece is added if it is missing
+	u, err = NewUnigram([]string{"a", "b"}, []float64{-1, -1}, WithWordStartMarker(""))
+	require.NoError(t, err)
+	assert.Equal(3, u.Vocab().Size())
+	assert.Equal([]int{0, 2}, u.EncodeIDs("ac"))
+}
+
+func TestUnigram_Sample(t *testing.T) {
+	assert := assert.New(t)
+	u, err := NewUnigram([]string{"<unk>", "▁ab", "▁a", "b", "▁", "a"}, []float64{0, -1, -2, -1, -3, -3})
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(1337))
+
+	// the segmentations of ▁ab have probabilities proportional to e^-1, e^-3 and e^-7
+	seen := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		pieces := u.Sample("ab", 1, rng)
+		assert.Equal("ab", u.Decode(pieces))
+		seen[strings.Join(pieces, " ")]++
+	}
+	z := math.Exp(-1) + math.Exp(-3) + math.Exp(-7)
+	assert.InDelta(math.Exp(-1)/z, float64(seen["▁ab"])/2000, 0.03)
+	assert.InDelta(math.Exp(-3)/z, float64(seen["▁a b"])/2000, 0.03)
+	assert.Equal(3, len(seen))
+
+	// with a large alpha, samples are the best segmentation
+	for i := 0; i < 20; i++ {
+		assert.Equal([]string{"▁ab"}, u.Sample("ab", 50, rng))
+	}
+	assert.Equal([]string{"▁"}, u.Sample("", 1, rng))
+	assert.Equal([]string{"<unk>"}, u.Sample("<unk>", 1, rng))
+	assert.Equal([]int{1}, u.SampleIDs("ab", 50, rng))
+	assert.Equal([]int{1, 0}, u.SampleIDs("abz", 50, rng))
+}
+
+func TestUnigram_EncodeTokens(t *testing.T) {
+	assert := assert.New(t)
+	u, err := NewUnigram([]string{"<unk>", "▁", "a", "b", "▁ab", "x"}, []float64{0, -2, -1, -1, -1.5, -5})
+	require.NoError(t, err)
+
+	tokens := u.EncodeTokens(AlignTokens("é abza ba", []string{"abza", "ba"}))
+	assert.Equal([]Token{
+		{Text: "▁ab", Start: 3, End: 5, RuneStart: 2, RuneEnd: 4, ID: 4},
+		{Text: "z", Start: 5, End: 6, RuneStart: 4, RuneEnd: 5, ID: 0},
+		{Text: "a", Start: 6, End: 7, RuneStart: 5, RuneEnd: 6, ID: 2},
+		// the word start marker on its own covers nothing
+		{Text: "▁", Start: 8, End: 8, RuneStart: 7, RuneEnd: 7, ID: 1},
+		{Text: "b", Start: 8, End: 9, RuneStart: 7, RuneEnd: 8, ID: 3},
+		{Text: "a", Start: 9, End: 10, RuneStart: 8, RuneEnd: 9, ID: 2},
+	}, tokens)
+
+	tokens = u.EncodeTokens(AlignTokens("<unk>", []string{"<unk>"}))
+	assert.Equal([]Token{{Text: "<unk>", Start: 0, End: 5, RuneStart: 0, RuneEnd: 5, ID: 0}}, tokens)
+}
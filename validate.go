@@ -0,0 +1,176 @@
+package corpus
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// specialWords are the words added by New. They don't count towards the maximum word length.
+var specialWords = map[string]struct{}{
+	"":          {},
+	"-UNKNOWN-": {},
+	"-ROOT-":    {},
+}
+
+// isSpecialIn reports whether the word is special in the vocabulary: one of the words added by New, or, for vocabularies that tell
+// (such as a Corpus with signature entries), a word the vocabulary considers special. Special words are left out of training.
+func isSpecialIn(v Vocabulary, w string) bool {
+	if _, ok := specialWords[w]; ok {
+		return true
+	}
+	if s, ok := v.(interface{ IsSpecial(string) bool }); ok {
+		return s.IsSpecial(w)
+	}
+	return false
+}
+
+// InvariantError describes a single inconsistency found by (*Corpus).Validate.
+type InvariantError struct {
+	Field string // the field of the Corpus that is inconsistent: "maxid", "frequencies", "ids", "totalFreq" or "maxWordLength"
+	ID    int    // the ID of the offending entry, or -1 if the inconsistency isn't about a particular entry
+	Word  string // the offending word, if any
+	Msg   string
+}
+
+func (e InvariantError) Error() string {
+	if e.ID < 0 {
+		return fmt.Sprintf("%v: %v", e.Field, e.Msg)
+	}
+	return fmt.Sprintf("%v: ID %d (%q): %v", e.Field, e.ID, e.Word, e.Msg)
+}
+
+// InvariantErrors is a list of all the inconsistencies found by (*Corpus).Validate.
+type InvariantErrors []InvariantError
+
+func (es InvariantErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("Corpus has %d inconsistencies: %v", len(es), strings.Join(msgs, "; "))
+}
+
+// Validate checks that the internal tables and derived fields of the corpus agree with one another.
+// It returns nil if the corpus is consistent, and InvariantErrors listing every inconsistency otherwise.
+//
+// Old words left behind by Replace and ReplaceWord are not inconsistencies, as long as they refer to valid IDs.
+func (c *Corpus) Validate() error {
+	var errs InvariantErrors
+	fail := func(field string, id int, word string, format string, args ...interface{}) {
+		errs = append(errs, InvariantError{Field: field, ID: id, Word: word, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	maxid := int(atomic.LoadInt64(&c.maxid))
+	if maxid != len(c.words) {
+		fail("maxid", -1, "", "maxid is %d but there are %d words", maxid, len(c.words))
+	}
+	if len(c.frequencies) != len(c.words) {
+		fail("frequencies", -1, "", "there are %d frequencies but %d words", len(c.frequencies), len(c.words))
+	}
+
+	var totalFreq int
+	for id, f := range c.frequencies {
+		if f < 0 {
+			var w string
+			if id < len(c.words) {
+				w = c.words[id]
+			}
+			fail("frequencies", id, w, "frequency is negative (%d)", f)
+		}
+		totalFreq += f
+	}
+	if totalFreq != c.totalFreq {
+		fail("totalFreq", -1, "", "totalFreq is %d but the frequencies sum to %d", c.totalFreq, totalFreq)
+	}
+
+	for id, w := range c.words {
+		got, ok := c.ids[w]
+		switch {
+		case !ok:
+			fail("ids", id, w, "word has no ID")
+		case got != id:
+			fail("ids", id, w, "word is mapped to ID %d", got)
+		}
+	}
+	for w, id := range c.ids {
+		if id < 0 || id >= len(c.words) {
+			fail("ids", id, w, "ID is out of bounds [0, %d)", len(c.words))
+		}
+	}
+
+	longest, longestAll := c.longestWords()
+	if c.maxWordLength < longest || c.maxWordLength > longestAll {
+		fail("maxWordLength", -1, "", "maxWordLength is %d but the longest word has %d runes", c.maxWordLength, longest)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Repair recomputes the derived fields of the corpus (maxid, the frequency table's length, the ID of every word,
+// totalFreq and maxWordLength) from the word list, and rebuilds the prefix index if there is one.
+// Negative frequencies are reset to 0.
+//
+// Some inconsistencies, such as a word appearing twice in the word list, cannot be repaired without changing IDs.
+// Those are returned as InvariantErrors.
+func (c *Corpus) Repair() error {
+	atomic.StoreInt64(&c.maxid, int64(len(c.words)))
+
+	switch {
+	case len(c.frequencies) > len(c.words):
+		c.frequencies = c.frequencies[:len(c.words)]
+	case len(c.frequencies) < len(c.words):
+		c.frequencies = append(c.frequencies, make([]int, len(c.words)-len(c.frequencies))...)
+	}
+
+	c.totalFreq = 0
+	for id, f := range c.frequencies {
+		if f < 0 {
+			c.frequencies[id] = 0
+			f = 0
+		}
+		c.totalFreq += f
+	}
+
+	if c.ids == nil {
+		c.ids = make(map[string]int)
+	}
+	for w, id := range c.ids {
+		if id < 0 || id >= len(c.words) {
+			delete(c.ids, w)
+		}
+	}
+	for id := len(c.words) - 1; id >= 0; id-- {
+		// iterating backwards so that the first occurrence of a duplicate wins
+		c.ids[c.words[id]] = id
+	}
+
+	c.maxWordLength, _ = c.longestWords()
+
+	if c.trie != nil {
+		c.BuildTrie()
+	}
+
+	return c.Validate()
+}
+
+// longestWords returns the length (in runes) of the longest word that isn't special (see IsSpecial), and the length of the longest word overall.
+func (c *Corpus) longestWords() (longest, longestAll int) {
+	for _, w := range c.words {
+		runeCount := utf8.RuneCountInString(w)
+		if runeCount > longestAll {
+			longestAll = runeCount
+		}
+		if c.IsSpecial(w) {
+			continue
+		}
+		if runeCount > longest {
+			longest = runeCount
+		}
+	}
+	return
+}
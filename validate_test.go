@@ -0,0 +1,65 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorpus_Validate(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	c.Add("hello")
+	c.Add("world")
+	assert.NoError(c.Validate())
+
+	require.NoError(t, c.Replace("hello", "goodbye"))
+	assert.NoError(c.Validate(), "Replace should keep the corpus consistent")
+	assert.Equal(7, c.MaxWordLength())
+
+	// corrupt the corpus
+	c.totalFreq += 10
+	c.maxWordLength = 2
+	c.ids["world"] = 100
+	c.frequencies = append(c.frequencies, 3)
+
+	err := c.Validate()
+	require.Error(t, err)
+	errs, ok := err.(InvariantErrors)
+	require.True(t, ok)
+
+	fields := make(map[string]int)
+	for _, e := range errs {
+		fields[e.Field]++
+	}
+	assert.Equal(map[string]int{"frequencies": 1, "totalFreq": 1, "ids": 2, "maxWordLength": 1}, fields)
+
+	require.NoError(t, c.Repair())
+	assert.Equal(c.Size(), len(c.frequencies))
+	assert.Equal(5, c.TotalFreq())
+	assert.Equal(7, c.MaxWordLength())
+	id, ok := c.Id("world")
+	assert.True(ok)
+	assert.Equal(4, id)
+	id, ok = c.Id("hello")
+	assert.True(ok, "old references should survive a repair")
+	assert.Equal(3, id)
+}
+
+func TestCorpus_Repair(t *testing.T) {
+	c := New()
+	c.Add("hello")
+	c.words = append(c.words, "hello")
+	c.frequencies = append(c.frequencies, 1)
+	c.totalFreq++
+	c.maxid++
+
+	err := c.Repair()
+	require.Error(t, err)
+	errs := err.(InvariantErrors)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "ids", errs[0].Field)
+	assert.Equal(t, 4, errs[0].ID)
+	assert.Equal(t, "hello", errs[0].Word)
+}
@@ -0,0 +1,220 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync/atomic"
+)
+
+// Vocab is a generic vocabulary over any comparable token type. Like Corpus, it assigns an ID to each token in order of first
+// appearance and keeps track of how often each token was seen. This is useful for vocabularies of things that aren't strings,
+// such as POS tag pairs, byte sequences or integer feature tuples.
+//
+// Corpus is the string specialization of Vocab.
+type Vocab[T comparable] struct {
+	words       []T
+	frequencies []int
+
+	ids map[T]int
+
+	// atomic read and write plz
+	maxid     int64
+	totalFreq int
+}
+
+// NewVocab creates a new, empty *Vocab.
+func NewVocab[T comparable]() *Vocab[T] {
+	v := makeVocab[T](0)
+	return &v
+}
+
+// makeVocab creates a Vocab value with its tables preallocated to the given size.
+func makeVocab[T comparable](size int) Vocab[T] {
+	return Vocab[T]{
+		words:       make([]T, 0, size),
+		frequencies: make([]int, 0, size),
+		ids:         make(map[T]int, size),
+	}
+}
+
+// Id returns the ID of a word and whether or not it was found in the vocabulary
+func (v *Vocab[T]) Id(word T) (int, bool) {
+	id, ok := v.ids[word]
+	return id, ok
+}
+
+// Word returns the word given the ID, and whether or not it was found in the vocabulary
+func (v *Vocab[T]) Word(id int) (T, bool) {
+	size := atomic.LoadInt64(&v.maxid)
+	maxid := int(size)
+
+	if id >= maxid {
+		var zero T
+		return zero, false
+	}
+	return v.words[id], true
+}
+
+// Add adds a word to the vocabulary and returns its ID. If a word was previously in the vocabulary, it merely updates the frequency count and returns the ID
+func (v *Vocab[T]) Add(word T) int {
+	if id, ok := v.ids[word]; ok {
+		v.frequencies[id]++
+		v.totalFreq++
+		return id
+	}
+
+	id := atomic.AddInt64(&v.maxid, 1)
+	v.ids[word] = int(id - 1)
+	v.words = append(v.words, word)
+	v.frequencies = append(v.frequencies, 1)
+	v.totalFreq++
+	return int(id - 1)
+}
+
+// AddN adds n occurrences of a word to the vocabulary and returns its ID. If n is 0, the word is merely registered.
+func (v *Vocab[T]) AddN(word T, n int) int {
+	id := v.Add(word)
+	v.frequencies[id] += n - 1
+	v.totalFreq += n - 1
+	return id
+}
+
+// Size returns the size of the vocabulary.
+func (v *Vocab[T]) Size() int {
+	size := atomic.LoadInt64(&v.maxid)
+	return int(size)
+}
+
+// WordFreq returns the frequency of the word. If the word wasn't in the vocabulary, it returns 0.
+func (v *Vocab[T]) WordFreq(word T) int {
+	id, ok := v.ids[word]
+	if !ok {
+		return 0
+	}
+
+	return v.frequencies[id]
+}
+
+// IDFreq returns the frequency of a word given an ID. If the word isn't in the vocabulary it returns 0.
+func (v *Vocab[T]) IDFreq(id int) int {
+	size := atomic.LoadInt64(&v.maxid)
+	maxid := int(size)
+
+	if id >= maxid {
+		return 0
+	}
+	return v.frequencies[id]
+}
+
+// TotalFreq returns the total number of words ever seen by the vocabulary. This number includes the count of repeat words.
+func (v *Vocab[T]) TotalFreq() int {
+	return v.totalFreq
+}
+
+// WordProb returns the probability of a word appearing in the vocabulary.
+func (v *Vocab[T]) WordProb(word T) (float64, bool) {
+	id, ok := v.Id(word)
+	if !ok {
+		return 0, false
+	}
+
+	count := v.frequencies[id]
+	return float64(count) / float64(v.totalFreq), true
+}
+
+// Merge combines two vocabularies. The receiver is the one that is mutated.
+func (v *Vocab[T]) Merge(other *Vocab[T]) {
+	for i, word := range other.words {
+		v.AddN(word, other.frequencies[i])
+	}
+}
+
+// Prune removes all words whose frequency is less than minFreq, except for the words listed in keep.
+// The remaining words keep their relative order, but are assigned new, contiguous IDs.
+// The total frequency is reduced by the frequencies of the removed words.
+func (v *Vocab[T]) Prune(minFreq int, keep ...T) {
+	kept := make(map[T]struct{}, len(keep))
+	for _, w := range keep {
+		kept[w] = struct{}{}
+	}
+
+	words := v.words[:0]
+	frequencies := v.frequencies[:0]
+	ids := make(map[T]int, len(v.ids))
+	var totalFreq int
+	for i, w := range v.words {
+		freq := v.frequencies[i]
+		if _, ok := kept[w]; !ok && freq < minFreq {
+			continue
+		}
+		ids[w] = len(words)
+		words = append(words, w)
+		frequencies = append(frequencies, freq)
+		totalFreq += freq
+	}
+
+	v.words = words
+	v.frequencies = frequencies
+	v.ids = ids
+	v.totalFreq = totalFreq
+	atomic.StoreInt64(&v.maxid, int64(len(words)))
+}
+
+// GobEncode implements GobEncoder for *Vocab
+func (v *Vocab[T]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	if err := v.encode(encoder); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements GobDecoder for *Vocab
+func (v *Vocab[T]) GobDecode(buf []byte) error {
+	b := bytes.NewBuffer(buf)
+	decoder := gob.NewDecoder(b)
+	return v.decode(decoder)
+}
+
+// encode writes the fields of the vocabulary to the encoder. The order of the fields is the wire format, so don't change it.
+func (v *Vocab[T]) encode(encoder *gob.Encoder) error {
+	if err := encoder.Encode(v.words); err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(v.ids); err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(v.frequencies); err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(v.maxid); err != nil {
+		return err
+	}
+
+	return encoder.Encode(v.totalFreq)
+}
+
+// decode reads the fields of the vocabulary from the decoder, in the order written by encode.
+func (v *Vocab[T]) decode(decoder *gob.Decoder) error {
+	if err := decoder.Decode(&v.words); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&v.ids); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&v.frequencies); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(&v.maxid); err != nil {
+		return err
+	}
+
+	return decoder.Decode(&v.totalFreq)
+}
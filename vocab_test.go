@@ -0,0 +1,91 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type posPair struct{ Left, Right string }
+
+func TestVocab(t *testing.T) {
+	assert := assert.New(t)
+	v := NewVocab[posPair]()
+	assert.Equal(0, v.WordFreq(posPair{"DT", "NN"}))
+
+	id := v.Add(posPair{"DT", "NN"})
+	assert.Equal(0, id)
+	id = v.Add(posPair{"NN", "VBZ"})
+	assert.Equal(1, id)
+	id = v.Add(posPair{"DT", "NN"})
+	assert.Equal(0, id)
+
+	assert.Equal(2, v.Size())
+	assert.Equal(3, v.TotalFreq())
+	assert.Equal(2, v.WordFreq(posPair{"DT", "NN"}))
+	assert.Equal(1, v.IDFreq(1))
+
+	w, ok := v.Word(1)
+	assert.True(ok)
+	assert.Equal(posPair{"NN", "VBZ"}, w)
+	_, ok = v.Word(2)
+	assert.False(ok)
+
+	prob, ok := v.WordProb(posPair{"NN", "VBZ"})
+	assert.True(ok)
+	assert.True(floatEquals64(1.0/3.0, prob))
+}
+
+func TestVocab_Merge(t *testing.T) {
+	assert := assert.New(t)
+	v := NewVocab[[3]int]()
+	v.Add([3]int{1, 2, 3})
+
+	other := NewVocab[[3]int]()
+	other.Add([3]int{1, 2, 3})
+	other.Add([3]int{4, 5, 6})
+	other.Add([3]int{4, 5, 6})
+
+	v.Merge(other)
+	assert.Equal(2, v.Size())
+	assert.Equal(2, v.WordFreq([3]int{1, 2, 3}))
+	assert.Equal(2, v.WordFreq([3]int{4, 5, 6}))
+	assert.Equal(4, v.TotalFreq())
+}
+
+func TestVocab_Prune(t *testing.T) {
+	assert := assert.New(t)
+	v := NewVocab[string]()
+	for _, w := range []string{"<pad>", "a", "b", "a", "c", "c", "c"} {
+		v.Add(w)
+	}
+
+	v.Prune(2, "<pad>")
+	assert.Equal([]string{"<pad>", "a", "c"}, v.words)
+	assert.Equal(map[string]int{"<pad>": 0, "a": 1, "c": 2}, v.ids)
+	assert.Equal(3, v.Size())
+	assert.Equal(6, v.TotalFreq())
+
+	c, err := Construct(WithWords([]string{"hello", "hello", "supercalifragilistic", "world", "world"}))
+	require.NoError(t, err)
+	c.Prune(2)
+	assert.Equal([]string{"hello", "world"}, c.words)
+	assert.Equal(5, c.MaxWordLength())
+}
+
+func TestVocabGob(t *testing.T) {
+	v := NewVocab[posPair]()
+	v.Add(posPair{"DT", "NN"})
+	v.Add(posPair{"NN", "VBZ"})
+	v.Add(posPair{"NN", "VBZ"})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, gob.NewEncoder(buf).Encode(v))
+
+	v2 := NewVocab[posPair]()
+	require.NoError(t, gob.NewDecoder(buf).Decode(v2))
+	assert.Equal(t, v, v2)
+}
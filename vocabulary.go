@@ -0,0 +1,46 @@
+package corpus
+
+// Vocabulary is the read-only view of a vocabulary of words. Functions in this package that only need to look words up
+// (such as ViterbiSplit) accept a Vocabulary, so that alternative backends (tries, memory mapped tables, hashed vocabularies and so on)
+// may be used in place of a *Corpus.
+type Vocabulary interface {
+	// Id returns the ID of a word and whether or not it was found in the vocabulary.
+	Id(word string) (int, bool)
+
+	// Word returns the word given the ID, and whether or not it was found in the vocabulary.
+	Word(id int) (string, bool)
+
+	// WordFreq returns the frequency of the word. If the word isn't known, it returns 0.
+	WordFreq(word string) int
+
+	// WordProb returns the probability of a word appearing in the vocabulary.
+	WordProb(word string) (float64, bool)
+
+	// Size returns the number of words in the vocabulary.
+	Size() int
+
+	// TotalFreq returns the total number of words seen, including repeats.
+	TotalFreq() int
+
+	// MaxWordLength returns the length (in runes) of the longest known word.
+	MaxWordLength() int
+}
+
+// Builder is a Vocabulary that words can be added to. Loaders such as LoadOneGram accept a Builder.
+type Builder interface {
+	Vocabulary
+
+	// Add adds a word to the vocabulary and returns its ID.
+	Add(word string) int
+
+	// AddN adds n occurrences of a word to the vocabulary and returns its ID.
+	AddN(word string, n int) int
+}
+
+var (
+	_ Vocabulary = (*Corpus)(nil)
+	_ Builder    = (*Corpus)(nil)
+	_ Builder    = (*CompactCorpus)(nil)
+	_ Vocabulary = (*Mixture)(nil)
+	_ Vocabulary = (*HashingVocab)(nil)
+)
@@ -0,0 +1,83 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapVocab is a minimal Builder backed by a map, used to check that the package works with non-Corpus vocabularies.
+type mapVocab struct {
+	freqs     map[string]int
+	words     []string
+	total     int
+	maxLength int
+}
+
+func newMapVocab() *mapVocab { return &mapVocab{freqs: make(map[string]int)} }
+
+func (v *mapVocab) Id(word string) (int, bool) {
+	for i, w := range v.words {
+		if w == word {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (v *mapVocab) Word(id int) (string, bool) {
+	if id < 0 || id >= len(v.words) {
+		return "", false
+	}
+	return v.words[id], true
+}
+
+func (v *mapVocab) WordFreq(word string) int { return v.freqs[word] }
+func (v *mapVocab) WordProb(word string) (float64, bool) {
+	f, ok := v.freqs[word]
+	return float64(f) / float64(v.total), ok
+}
+func (v *mapVocab) Size() int           { return len(v.words) }
+func (v *mapVocab) TotalFreq() int      { return v.total }
+func (v *mapVocab) MaxWordLength() int  { return v.maxLength }
+func (v *mapVocab) Add(word string) int { return v.AddN(word, 1) }
+func (v *mapVocab) AddN(word string, n int) int {
+	if _, ok := v.freqs[word]; !ok {
+		v.words = append(v.words, word)
+	}
+	v.freqs[word] += n
+	v.total += n
+	if l := utf8.RuneCountInString(word); l > v.maxLength {
+		v.maxLength = l
+	}
+	id, _ := v.Id(word)
+	return id
+}
+
+func TestVocabulary(t *testing.T) {
+	assert := assert.New(t)
+	v := newMapVocab()
+	require.NoError(t, LoadOneGram(strings.NewReader(sample1Gram), v))
+	assert.Equal(7, v.Size())
+	assert.Equal(5933321709, v.WordFreq("for"))
+
+	words := ViterbiSplit("ofthe", v)
+	assert.Equal([]string{"of", "the"}, words)
+}
+
+func TestCorpus_AddN(t *testing.T) {
+	assert := assert.New(t)
+	c := New()
+	id := c.AddN("hello", 5)
+	assert.Equal(3, id)
+	assert.Equal(5, c.WordFreq("hello"))
+	assert.Equal(8, c.TotalFreq())
+	assert.Equal(5, c.MaxWordLength())
+
+	c.AddN("hello", 2)
+	assert.Equal(7, c.WordFreq("hello"))
+	assert.Equal(10, c.TotalFreq())
+}
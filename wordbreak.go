@@ -0,0 +1,215 @@
+package corpus
+
+import "unicode"
+
+// wbClass is the Word_Break property of a rune, as defined by Unicode Standard Annex #29.
+type wbClass byte
+
+const (
+	wbOther wbClass = iota
+	wbCR
+	wbLF
+	wbNewline
+	wbExtend
+	wbZWJ
+	wbRegionalIndicator
+	wbFormat
+	wbKatakana
+	wbHebrewLetter
+	wbALetter
+	wbSingleQuote
+	wbDoubleQuote
+	wbMidNumLet
+	wbMidLetter
+	wbMidNum
+	wbNumeric
+	wbExtendNumLet
+	wbWSegSpace
+	wbExtPict // Extended_Pictographic is a separate property, but the runes that have it are otherwise Other
+)
+
+// complexContext are the scripts that are written without spaces between words, and need a dictionary to be split into words (see DictTokenizer).
+// UAX #29 leaves their letters out of ALetter, so every one of them is a segment of its own.
+var complexContext = []*unicode.RangeTable{unicode.Thai, unicode.Lao, unicode.Myanmar, unicode.Khmer, unicode.Tai_Le, unicode.New_Tai_Lue, unicode.Tai_Tham, unicode.Tai_Viet}
+
+// wordBreakClass returns the Word_Break property of a rune. The property is derived from the general category and the script of the rune,
+// which is close enough to the Unicode data files for the runes found in text.
+func wordBreakClass(r rune) wbClass {
+	switch r {
+	case '\r':
+		return wbCR
+	case '\n':
+		return wbLF
+	case '\v', '\f', 0x85, 0x2028, 0x2029:
+		return wbNewline
+	case 0x200D:
+		return wbZWJ
+	case 0x200C, 0xFF9E, 0xFF9F:
+		return wbExtend
+	case '\'':
+		return wbSingleQuote
+	case '"':
+		return wbDoubleQuote
+	case '.', 0x2018, 0x2019, 0x2024, 0xFE52, 0xFF07, 0xFF0E:
+		return wbMidNumLet
+	case ':', 0xB7, 0x387, 0x55F, 0x5F4, 0x2027, 0xFE13, 0xFE55, 0xFF1A:
+		return wbMidLetter
+	case ',', ';', 0x37E, 0x589, 0x60C, 0x60D, 0x66C, 0x7F8, 0x2044, 0xFE10, 0xFE14, 0xFE50, 0xFE54, 0xFF0C, 0xFF1B:
+		return wbMidNum
+	case 0x202F:
+		return wbExtendNumLet
+	case 0x3031, 0x3032, 0x3033, 0x3034, 0x3035, 0x309B, 0x309C, 0x30A0, 0x30FC:
+		return wbKatakana
+	case 0x30FB:
+		return wbOther
+	}
+
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return wbRegionalIndicator
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return wbExtend
+	case r == 0x200B:
+		return wbOther
+	case unicode.Is(unicode.Cf, r):
+		return wbFormat
+	case unicode.Is(unicode.Nd, r):
+		return wbNumeric
+	case unicode.Is(unicode.Pc, r):
+		return wbExtendNumLet
+	case unicode.Is(unicode.Zs, r):
+		if r == 0xA0 || r == 0x2007 {
+			return wbOther
+		}
+		return wbWSegSpace
+	case unicode.Is(unicode.Katakana, r):
+		return wbKatakana
+	case unicode.Is(unicode.Hebrew, r) && unicode.IsLetter(r):
+		return wbHebrewLetter
+	case unicode.IsLetter(r) || unicode.Is(unicode.Nl, r):
+		if unicode.In(r, unicode.Ideographic, unicode.Hiragana) || unicode.In(r, complexContext...) {
+			return wbOther
+		}
+		return wbALetter
+	case (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF):
+		return wbExtPict
+	}
+	return wbOther
+}
+
+// isAHLetter reports whether the class is ALetter or Hebrew_Letter.
+func isAHLetter(c wbClass) bool { return c == wbALetter || c == wbHebrewLetter }
+
+// isMidNumLetQ reports whether the class is MidNumLet or Single_Quote.
+func isMidNumLetQ(c wbClass) bool { return c == wbMidNumLet || c == wbSingleQuote }
+
+// isWBIgnored reports whether rule WB4 attaches runes of the class to the rune before them.
+func isWBIgnored(c wbClass) bool { return c == wbExtend || c == wbFormat || c == wbZWJ }
+
+// wordSegments splits text into segments at the word boundaries of Unicode Standard Annex #29, and returns the byte offsets of their starts and ends.
+// Every rune of the text is in exactly one segment, so words, punctuation and runs of spaces are all segments.
+func wordSegments(text string) [][2]int {
+	offsets := runeOffsets(text)
+	n := len(offsets) - 1
+	if n == 0 {
+		return nil
+	}
+	classes := make([]wbClass, n)
+	for i, r := range []rune(text) {
+		classes[i] = wordBreakClass(r)
+	}
+
+	// prev and next return the index of the closest rune before or after i that isn't ignored by WB4, or -1.
+	prev := func(i int) int {
+		for i--; i >= 0; i-- {
+			if !isWBIgnored(classes[i]) {
+				return i
+			}
+		}
+		return -1
+	}
+	next := func(i int) int {
+		for i++; i < n; i++ {
+			if !isWBIgnored(classes[i]) {
+				return i
+			}
+		}
+		return -1
+	}
+	classAt := func(i int) wbClass {
+		if i < 0 {
+			return wbOther
+		}
+		return classes[i]
+	}
+
+	var retVal [][2]int
+	start := 0
+	for i := 1; i < n; i++ {
+		if !wordBreakBefore(classes, i, prev, next, classAt) {
+			continue
+		}
+		retVal = append(retVal, [2]int{offsets[start], offsets[i]})
+		start = i
+	}
+	return append(retVal, [2]int{offsets[start], offsets[n]})
+}
+
+// wordBreakBefore applies the rules of UAX #29 to decide whether there is a word boundary between the runes at i-1 and i.
+func wordBreakBefore(classes []wbClass, i int, prev, next func(int) int, classAt func(int) wbClass) bool {
+	a, b := classes[i-1], classes[i]
+	switch {
+	case a == wbCR && b == wbLF: // WB3
+		return false
+	case a == wbCR || a == wbLF || a == wbNewline: // WB3a
+		return true
+	case b == wbCR || b == wbLF || b == wbNewline: // WB3b
+		return true
+	case a == wbZWJ && b == wbExtPict: // WB3c
+		return false
+	case a == wbWSegSpace && b == wbWSegSpace: // WB3d
+		return false
+	case isWBIgnored(b): // WB4
+		return false
+	}
+
+	p := prev(i)
+	if p < 0 {
+		return true
+	}
+	a = classes[p]
+	aa, bb := classAt(prev(p)), classAt(next(i))
+	switch {
+	case isAHLetter(a) && isAHLetter(b): // WB5
+		return false
+	case isAHLetter(a) && (b == wbMidLetter || isMidNumLetQ(b)) && isAHLetter(bb): // WB6
+		return false
+	case isAHLetter(aa) && (a == wbMidLetter || isMidNumLetQ(a)) && isAHLetter(b): // WB7
+		return false
+	case a == wbHebrewLetter && b == wbSingleQuote: // WB7a
+		return false
+	case a == wbHebrewLetter && b == wbDoubleQuote && bb == wbHebrewLetter: // WB7b
+		return false
+	case aa == wbHebrewLetter && a == wbDoubleQuote && b == wbHebrewLetter: // WB7c
+		return false
+	case a == wbNumeric && b == wbNumeric, isAHLetter(a) && b == wbNumeric, a == wbNumeric && isAHLetter(b): // WB8, WB9, WB10
+		return false
+	case aa == wbNumeric && (a == wbMidNum || isMidNumLetQ(a)) && b == wbNumeric: // WB11
+		return false
+	case a == wbNumeric && (b == wbMidNum || isMidNumLetQ(b)) && bb == wbNumeric: // WB12
+		return false
+	case a == wbKatakana && b == wbKatakana: // WB13
+		return false
+	case (isAHLetter(a) || a == wbNumeric || a == wbKatakana || a == wbExtendNumLet) && b == wbExtendNumLet: // WB13a
+		return false
+	case a == wbExtendNumLet && (isAHLetter(b) || b == wbNumeric || b == wbKatakana): // WB13b
+		return false
+	case a == wbRegionalIndicator && b == wbRegionalIndicator: // WB15, WB16: flags are pairs of regional indicators
+		count := 0
+		for j := p; j >= 0 && classes[j] == wbRegionalIndicator; j = prev(j) {
+			count++
+		}
+		return count%2 == 0
+	}
+	return true // WB999
+}
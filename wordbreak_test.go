@@ -0,0 +1,41 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func segmentStrings(text string) []string {
+	var retVal []string
+	for _, s := range wordSegments(text) {
+		retVal = append(retVal, text[s[0]:s[1]])
+	}
+	return retVal
+}
+
+func TestWordSegments(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"The quick (\"brown\") fox can't jump 32.3 feet, right?",
+			[]string{"The", " ", "quick", " ", "(", "\"", "brown", "\"", ")", " ", "fox", " ", "can't", " ", "jump", " ", "32.3", " ", "feet", ",", " ", "right", "?"}},
+		{"ALICE'S  ADVENTURES", []string{"ALICE'S", "  ", "ADVENTURES"}},
+		{"Rabbit-Hole", []string{"Rabbit", "-", "Hole"}},
+		{"1,000,000 e.g. a3 x_y", []string{"1,000,000", " ", "e.g", ".", " ", "a3", " ", "x_y"}},
+		{"it's.", []string{"it's", "."}},
+		{"\r\n\n", []string{"\r\n", "\n"}},
+		{"naïve café", []string{"naïve", " ", "café"}},     // precomposed
+		{"naïve café", []string{"naïve", " ", "café"}}, // combining marks stay with their letters
+		{"カタカナ日本語", []string{"カタカナ", "日", "本", "語"}},
+		{"🇫🇷🇩🇪", []string{"🇫🇷", "🇩🇪"}},
+		{"👨‍👩", []string{"👨‍👩"}},
+		{"שב\"כ", []string{"שב\"כ"}},
+	}
+	for _, c := range cases {
+		assert.Equal(c.want, segmentStrings(c.text), "%q", c.text)
+	}
+}
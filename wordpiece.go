@@ -0,0 +1,403 @@
+package corpus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WordPieceOpt is an option for NewWordPiece.
+type WordPieceOpt func(wp *WordPiece)
+
+// WithContinuationPrefix sets the prefix that marks subwords which continue a word. The default is "##".
+func WithContinuationPrefix(prefix string) WordPieceOpt {
+	return func(wp *WordPiece) { wp.prefix = prefix }
+}
+
+// WithUnknownPiece sets the token that words which cannot be split into known subwords become. The default is "[UNK]".
+func WithUnknownPiece(token string) WordPieceOpt {
+	return func(wp *WordPiece) { wp.unknown = token }
+}
+
+// WithMaxInputChars sets the length (in runes) above which words are not split, but become the unknown token. The default is 100.
+func WithMaxInputChars(n int) WordPieceOpt {
+	return func(wp *WordPiece) { wp.maxChars = n }
+}
+
+// Cased turns off lowercasing and accent stripping, for cased models such as bert-base-cased.
+func Cased() WordPieceOpt {
+	return func(wp *WordPiece) { wp.lowercase, wp.stripAccents = false, false }
+}
+
+// WithStripAccents turns accent stripping on or off, whether or not the text is lowercased. By default accents are stripped, unless the tokenizer is Cased.
+func WithStripAccents(strip bool) WordPieceOpt {
+	return func(wp *WordPiece) { wp.stripAccents = strip }
+}
+
+// WithCleanText turns the removal of control characters on or off. It is on by default, as in BERT.
+func WithCleanText(clean bool) WordPieceOpt {
+	return func(wp *WordPiece) { wp.cleanText = clean }
+}
+
+// WithChineseChars turns splitting CJK ideographs into tokens of their own on or off. It is on by default, as in BERT.
+func WithChineseChars(split bool) WordPieceOpt {
+	return func(wp *WordPiece) { wp.chineseChars = split }
+}
+
+// WithUnicodeForm normalizes text to the given Unicode normalization form before it is pre-tokenized. By default text is not normalized.
+func WithUnicodeForm(form norm.Form) WordPieceOpt {
+	return func(wp *WordPiece) { wp.form, wp.normalize = form, true }
+}
+
+// WithNeverSplit sets the tokens that pre-tokenization leaves alone. The default is BERT's special tokens: [UNK], [SEP], [PAD], [CLS] and [MASK].
+func WithNeverSplit(tokens ...string) WordPieceOpt {
+	return func(wp *WordPiece) {
+		wp.neverSplit = make(map[string]struct{}, len(tokens))
+		for _, t := range tokens {
+			wp.neverSplit[t] = struct{}{}
+		}
+	}
+}
+
+// WordPiece is the subword tokenizer of BERT and the models derived from it. It pre-tokenizes text the way BERT's BasicTokenizer does, and then
+// splits each word into the longest subwords of the vocabulary it starts with, greedily, from left to right.
+type WordPiece struct {
+	vocab      Vocabulary
+	prefix     string
+	unknown    string
+	maxChars   int
+	neverSplit map[string]struct{}
+
+	lowercase, stripAccents bool
+	cleanText, chineseChars bool
+	form                    norm.Form
+	normalize               bool // whether form is used
+}
+
+// NewWordPiece creates a WordPiece tokenizer over the given vocabulary of subwords, such as one loaded by LoadWordPieceVocab.
+// By default it behaves like the tokenizer of the uncased BERT models.
+func NewWordPiece(vocab Vocabulary, opts ...WordPieceOpt) *WordPiece {
+	wp := &WordPiece{
+		vocab:    vocab,
+		prefix:   "##",
+		unknown:  "[UNK]",
+		maxChars: 100,
+
+		lowercase:    true,
+		stripAccents: true,
+		cleanText:    true,
+		chineseChars: true,
+	}
+	WithNeverSplit("[UNK]", "[SEP]", "[PAD]", "[CLS]", "[MASK]")(wp)
+	for _, opt := range opts {
+		opt(wp)
+	}
+	return wp
+}
+
+// LoadWordPieceVocab loads a BERT vocab.txt, which holds one token per line. The ID of each token is its line number, counting from 0.
+// A token listed more than once gets the number of its last line, as in the reference implementation. As a Corpus cannot give a token two IDs,
+// the lines before are given placeholder tokens such as "[DUPLICATE-2]", which no text is split into, so that the IDs of the other tokens stay line numbers.
+func LoadWordPieceVocab(r io.Reader) (*Corpus, error) {
+	var tokens []string
+	lines := make(map[string]int) // the last line of every token
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		token := strings.TrimRight(scanner.Text(), "\r")
+		lines[token] = len(tokens)
+		tokens = append(tokens, token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Cannot read vocabulary")
+	}
+
+	for i, token := range tokens {
+		if lines[token] == i {
+			continue
+		}
+		placeholder := fmt.Sprintf("[DUPLICATE-%d]", i)
+		for _, ok := lines[placeholder]; ok; _, ok = lines[placeholder] {
+			placeholder += "#"
+		}
+		lines[placeholder] = i
+		tokens[i] = placeholder
+	}
+	return Construct(WithOrderedWords(tokens))
+}
+
+// Tokenize pre-tokenizes the text and splits every word into subwords. It may be passed to FromTextCorpus as its tokenizer.
+func (wp *WordPiece) Tokenize(text string) []string {
+	retVal := make([]string, 0)
+	for _, w := range wp.BasicTokenize(text) {
+		retVal = append(retVal, wp.Split(w)...)
+	}
+	return retVal
+}
+
+// Encode tokenizes the text and returns the IDs of the subwords. Subwords missing from the vocabulary get the ID of the unknown token,
+// or -1 if the vocabulary doesn't have it.
+func (wp *WordPiece) Encode(text string) []int {
+	subwords := wp.Tokenize(text)
+	unk := wp.unknownID()
+	retVal := make([]int, len(subwords))
+	for i, s := range subwords {
+		id, ok := wp.vocab.Id(s)
+		if !ok {
+			id = unk
+		}
+		retVal[i] = id
+	}
+	return retVal
+}
+
+// EncodeTokens pre-tokenizes every token the way BasicTokenize does and splits it into subwords, which keep the offsets of the parts of the token they cover.
+// See Token. Subwords of words that become the unknown token cover the whole word, and accents that are stripped belong to the subword of the letter they are on.
+// IDs are given as by Encode.
+func (wp *WordPiece) EncodeTokens(tokens []Token) []Token {
+	unk := wp.unknownID()
+	retVal := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		if _, ok := wp.neverSplit[t.Text]; ok {
+			id, ok := wp.vocab.Id(t.Text)
+			if !ok {
+				id = unk
+			}
+			retVal = append(retVal, subToken(t, t.Text, id, 0, len(t.Text)))
+			continue
+		}
+
+		for _, w := range wp.basicWords(t.Text) {
+			pos := 0 // in runes of the normalized word
+			for i, piece := range wp.Split(w.text) {
+				id, ok := wp.vocab.Id(piece)
+				if !ok {
+					id = unk
+				}
+				n := utf8.RuneCountInString(piece)
+				if i > 0 {
+					n -= utf8.RuneCountInString(wp.prefix)
+				}
+				if piece == wp.unknown {
+					n = len(w.src) - pos
+				}
+				retVal = append(retVal, subToken(t, piece, id, w.src[pos][0], w.src[pos+n-1][1]))
+				pos += n
+			}
+		}
+	}
+	return retVal
+}
+
+// unknownID returns the ID of the unknown token, or -1 if it isn't in the vocabulary.
+func (wp *WordPiece) unknownID() int {
+	unk, ok := wp.vocab.Id(wp.unknown)
+	if !ok {
+		return -1
+	}
+	return unk
+}
+
+// normWord is a word pre-tokenized by basicWords, with the byte offsets in the original text of every one of its runes.
+type normWord struct {
+	text string
+	src  [][2]int
+}
+
+// basicWords is BasicTokenize for a single token, with offsets. The token is normalized one segment at a time, a segment being a rune and the
+// combining marks that follow it, which gives the same text as normalizing the whole token. Every rune of a normalized segment comes from the whole segment.
+func (wp *WordPiece) basicWords(text string) []normWord {
+	var retVal []normWord
+	var cur normWord
+	var buf strings.Builder
+	flush := func() {
+		if len(cur.src) > 0 {
+			cur.text = buf.String()
+			retVal = append(retVal, cur)
+		}
+		cur = normWord{}
+		buf.Reset()
+	}
+
+	segments := norm.NFC
+	if wp.normalize {
+		segments = wp.form
+	}
+	for i := 0; i < len(text); {
+		n := segments.NextBoundaryInString(text[i:], true)
+		if n <= 0 {
+			_, n = utf8.DecodeRuneInString(text[i:])
+		}
+		src := [2]int{i, i + n}
+		for _, r := range wp.normalizeWord(text[i : i+n]) {
+			switch {
+			case wp.cleanText && (r == 0 || r == utf8.RuneError || isBertControl(r)):
+			case isBertWhitespace(r):
+				flush()
+			case (wp.chineseChars && isCJKIdeograph(r)) || isBertPunct(r):
+				flush()
+				buf.WriteRune(r)
+				cur.src = append(cur.src, src)
+				flush()
+			default:
+				buf.WriteRune(r)
+				cur.src = append(cur.src, src)
+			}
+		}
+		i += n
+	}
+	flush()
+	return retVal
+}
+
+// normalizeWord applies the Unicode normalization form, lowercasing and accent stripping the tokenizer is set up with.
+func (wp *WordPiece) normalizeWord(w string) string {
+	if wp.normalize {
+		w = wp.form.String(w)
+	}
+	if wp.lowercase {
+		w = strings.ToLower(w)
+	}
+	if wp.stripAccents {
+		w = stripAccents(w)
+	}
+	return w
+}
+
+// Split splits a single word into subwords, taking the longest known subword at each point. Every subword but the first carries the continuation prefix.
+// If some part of the word does not start any known subword, the whole word becomes the unknown token.
+func (wp *WordPiece) Split(word string) []string {
+	if utf8.RuneCountInString(word) > wp.maxChars {
+		return []string{wp.unknown}
+	}
+
+	offsets := runeOffsets(word)
+	var retVal []string
+	for start := 0; start < len(offsets)-1; {
+		var piece string
+		end := len(offsets) - 1
+		for ; end > start; end-- {
+			candidate := word[offsets[start]:offsets[end]]
+			if start > 0 {
+				candidate = wp.prefix + candidate
+			}
+			if _, ok := wp.vocab.Id(candidate); ok {
+				piece = candidate
+				break
+			}
+		}
+		if end == start {
+			return []string{wp.unknown}
+		}
+		retVal = append(retVal, piece)
+		start = end
+	}
+	return retVal
+}
+
+// BasicTokenize pre-tokenizes text the way BERT's BasicTokenizer does: control characters are removed, Chinese characters and punctuation
+// become tokens of their own, the text is split on whitespace and, unless the tokenizer is cased, lowercased and stripped of accents.
+func (wp *WordPiece) BasicTokenize(text string) []string {
+	if wp.normalize {
+		text = wp.form.String(text)
+	}
+	var buf strings.Builder
+	for _, r := range text {
+		switch {
+		case wp.cleanText && (r == 0 || r == utf8.RuneError || isBertControl(r)):
+		case isBertWhitespace(r):
+			buf.WriteRune(' ')
+		case wp.chineseChars && isCJKIdeograph(r):
+			buf.WriteRune(' ')
+			buf.WriteRune(r)
+			buf.WriteRune(' ')
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	retVal := make([]string, 0)
+	for _, w := range strings.Fields(buf.String()) {
+		if _, ok := wp.neverSplit[w]; ok {
+			retVal = append(retVal, w)
+			continue
+		}
+		if wp.lowercase {
+			w = strings.ToLower(w)
+		}
+		if wp.stripAccents {
+			w = stripAccents(w)
+		}
+		retVal = append(retVal, splitPunct(w)...)
+	}
+	return retVal
+}
+
+// splitPunct makes every punctuation rune of the word a token of its own.
+func splitPunct(w string) []string {
+	var retVal []string
+	start := 0
+	for i, r := range w {
+		if !isBertPunct(r) {
+			continue
+		}
+		if i > start {
+			retVal = append(retVal, w[start:i])
+		}
+		size := utf8.RuneLen(r)
+		retVal = append(retVal, w[i:i+size])
+		start = i + size
+	}
+	if start < len(w) {
+		retVal = append(retVal, w[start:])
+	}
+	return retVal
+}
+
+// stripAccents decomposes the word, and removes the combining marks.
+func stripAccents(w string) string {
+	var buf strings.Builder
+	for _, r := range norm.NFD.String(w) {
+		if !unicode.Is(unicode.Mn, r) {
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+func isBertWhitespace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || unicode.Is(unicode.Zs, r)
+}
+
+func isBertControl(r rune) bool {
+	if r == '\t' || r == '\n' || r == '\r' {
+		return false
+	}
+	return unicode.In(r, unicode.Cc, unicode.Cf)
+}
+
+// isBertPunct reports whether the rune is punctuation. Like BERT, all non-alphanumeric ASCII characters count, such as $ and ^, which Unicode considers symbols.
+func isBertPunct(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isCJKIdeograph reports whether the rune is in one of the CJK Unified Ideographs blocks. Following BERT, Hiragana, Katakana and Hangul are not.
+func isCJKIdeograph(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) ||
+		(r >= 0x3400 && r <= 0x4DBF) ||
+		(r >= 0x20000 && r <= 0x2A6DF) ||
+		(r >= 0x2A700 && r <= 0x2B73F) ||
+		(r >= 0x2B740 && r <= 0x2B81F) ||
+		(r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0x2F800 && r <= 0x2FA1F)
+}
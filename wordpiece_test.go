@@ -0,0 +1,172 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/unicode/norm"
+)
+
+// The expected outputs in these tests are those of the reference BERT tokenizer, on the vocabulary used by its own tests.
+
+func loadBertVocab(t *testing.T) *Corpus {
+	f, err := os.Open("testdata/bert_vocab.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	c, err := LoadWordPieceVocab(f)
+	require.NoError(t, err)
+	return c
+}
+
+func TestLoadWordPieceVocab(t *testing.T) {
+	assert := assert.New(t)
+	c := loadBertVocab(t)
+	assert.Equal(13, c.Size())
+	id, ok := c.Id("[UNK]")
+	assert.True(ok)
+	assert.Equal(0, id)
+	id, ok = c.Id("##ing")
+	assert.True(ok)
+	assert.Equal(9, id)
+
+	// IDs are line numbers
+	c, err := LoadWordPieceVocab(strings.NewReader("a\r\nb\nc\n"))
+	require.NoError(t, err)
+	id, _ = c.Id("c")
+	assert.Equal(2, id)
+
+	// a repeated token gets the ID of its last line, and the lines before it are placeholders
+	c, err = LoadWordPieceVocab(strings.NewReader("a\nb\na\nc\n[DUPLICATE-0]\n"))
+	require.NoError(t, err)
+	assert.Equal(5, c.Size())
+	id, _ = c.Id("a")
+	assert.Equal(2, id)
+	id, _ = c.Id("c")
+	assert.Equal(3, id)
+	id, _ = c.Id("[DUPLICATE-0]")
+	assert.Equal(4, id)
+	w, _ := c.Word(0)
+	assert.Equal("[DUPLICATE-0]#", w)
+	assert.NoError(c.Validate())
+}
+
+func TestLoadWordPieceVocab_Gob(t *testing.T) {
+	assert := assert.New(t)
+	c := loadBertVocab(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(c))
+	decoded := new(Corpus)
+	require.NoError(t, gob.NewDecoder(&buf).Decode(decoded))
+	for id, w := range c.words {
+		got, ok := decoded.Id(w)
+		assert.True(ok)
+		assert.Equal(id, got, "%q", w)
+	}
+	assert.NoError(decoded.Validate())
+}
+
+func TestWordPiece(t *testing.T) {
+	assert := assert.New(t)
+	wp := NewWordPiece(loadBertVocab(t))
+
+	assert.Equal([]string{"un", "##want", "##ed", ",", "runn", "##ing"}, wp.Tokenize("UNwantéd,running"))
+	assert.Equal([]int{7, 4, 5, 10, 8, 9}, wp.Encode("UNwantéd,running"))
+
+	assert.Equal([]string{}, wp.Tokenize(""))
+	assert.Equal([]string{"un", "##want", "##ed", "runn", "##ing"}, wp.Tokenize("unwanted running"))
+	assert.Equal([]string{"[UNK]", "runn", "##ing"}, wp.Tokenize("unwantedX running"))
+	assert.Equal([]string{"[CLS]", "low", "[SEP]"}, wp.Tokenize("[CLS] LOW [SEP]"))
+
+	short := NewWordPiece(loadBertVocab(t), WithMaxInputChars(5))
+	assert.Equal([]string{"[UNK]", "low"}, short.Tokenize("lowest low"))
+}
+
+func TestWordPiece_MissingUnknown(t *testing.T) {
+	assert := assert.New(t)
+	c, err := LoadWordPieceVocab(strings.NewReader("cat\nsat\n"))
+	require.NoError(t, err)
+
+	// without the unknown token, unknown words are -1, not the ID of some other word
+	wp := NewWordPiece(c)
+	assert.Equal([]int{-1, 1}, wp.Encode("dog sat"))
+	assert.Equal([]int{-1, 1}, TokenIDs(wp.EncodeTokens(NewWordTokenizer().Tokens("dog sat"))))
+
+	wp = NewWordPiece(c, WithUnknownPiece("<unk>"))
+	assert.Equal([]int{0, -1}, wp.Encode("cat dog"))
+}
+
+func TestWordPiece_BasicTokenize(t *testing.T) {
+	assert := assert.New(t)
+	uncased := NewWordPiece(nil)
+	cased := NewWordPiece(nil, Cased())
+
+	assert.Equal([]string{"ah", "博", "推", "zz"}, uncased.BasicTokenize("ah博推zz"))
+	assert.Equal([]string{"hello", "!", "how", "are", "you", "?"}, uncased.BasicTokenize(" \tHeLLo!how  \n Are yoU?  "))
+	assert.Equal([]string{"hello"}, uncased.BasicTokenize("Héllo"))
+	assert.Equal([]string{"hallo", "!", "how", "are", "you", "?"}, uncased.BasicTokenize(" \tHäLLo!how  \n Are yoU?  "))
+	assert.Equal([]string{"HeLLo", "!", "how", "Are", "yoU", "?"}, cased.BasicTokenize(" \tHeLLo!how  \n Are yoU?  "))
+	assert.Equal([]string{"HäLLo", "!", "how", "Are", "yoU", "?"}, cased.BasicTokenize(" \tHäLLo!how  \n Are yoU?  "))
+
+	// control characters are removed, and every non-alphanumeric ASCII character is punctuation
+	assert.Equal([]string{"a", "$", "5", "^", "b"}, uncased.BasicTokenize("a\u0000​$5^b"))
+	assert.Equal([]string{"[UNK]", "[", "unk", "]"}, uncased.BasicTokenize("[UNK] [unk]"))
+}
+
+func TestWordPiece_EncodeTokens(t *testing.T) {
+	assert := assert.New(t)
+	wp := NewWordPiece(loadBertVocab(t))
+	text := "[CLS] UNwantéd,running xyz"
+	tokens := wp.EncodeTokens(NewWordTokenizer(KeepPunctuation()).Tokens(text))
+
+	var pieces, spans []string
+	for _, tok := range tokens {
+		pieces = append(pieces, tok.Text)
+		spans = append(spans, text[tok.Start:tok.End])
+		assert.Equal(text[tok.Start:tok.End], string([]rune(text)[tok.RuneStart:tok.RuneEnd]))
+	}
+	// the word tokenizer splits [CLS] into punctuation and a word, which BERT splits again
+	assert.Equal([]string{"[UNK]", "[UNK]", "[UNK]", "un", "##want", "##ed", ",", "runn", "##ing", "[UNK]"}, pieces)
+	assert.Equal([]string{"[", "CLS", "]", "UN", "want", "éd", ",", "runn", "ing", "xyz"}, spans)
+	assert.Equal(wp.Encode(text)[1:], TokenIDs(tokens)[3:])
+
+	// whole special tokens are kept, and combining accents belong to the letters they are on
+	tokens = wp.EncodeTokens(AlignTokens("[CLS] wantéd", []string{"[CLS]", "wantéd"}))
+	cls, _ := wp.vocab.Id("[CLS]")
+	assert.Equal(Token{Text: "[CLS]", Start: 0, End: 5, RuneStart: 0, RuneEnd: 5, ID: cls}, tokens[0])
+	assert.Equal(Token{Text: "##ed", Start: 10, End: 14, RuneStart: 10, RuneEnd: 13, ID: 5}, tokens[2])
+}
+
+func TestWordPiece_Normalization(t *testing.T) {
+	assert := assert.New(t)
+
+	// lowercased, but accents are kept
+	wp := NewWordPiece(nil, WithStripAccents(false))
+	assert.Equal([]string{"héllo"}, wp.BasicTokenize("Héllo"))
+	// cased, but accents are stripped
+	wp = NewWordPiece(nil, Cased(), WithStripAccents(true))
+	assert.Equal([]string{"Hello"}, wp.BasicTokenize("Héllo"))
+
+	// decomposed accents are composed again
+	wp = NewWordPiece(nil, WithStripAccents(false), WithUnicodeForm(norm.NFC))
+	assert.Equal([]string{"héllo"}, wp.BasicTokenize("Héllo"))
+	tokens := wp.basicWords("Héllo")
+	require.Len(t, tokens, 1)
+	assert.Equal("héllo", tokens[0].text)
+	assert.Equal([][2]int{{0, 1}, {1, 4}, {4, 5}, {5, 6}, {6, 7}}, tokens[0].src)
+
+	// ligatures are taken apart, and both letters come from the ligature
+	wp = NewWordPiece(nil, WithUnicodeForm(norm.NFKC))
+	assert.Equal([]string{"fine"}, wp.BasicTokenize("ﬁne"))
+	tokens = wp.basicWords("ﬁne")
+	assert.Equal([][2]int{{0, 3}, {0, 3}, {3, 4}, {4, 5}}, tokens[0].src)
+
+	// without cleaning and Chinese characters
+	wp = NewWordPiece(nil, WithCleanText(false), WithChineseChars(false))
+	assert.Equal([]string{"a\u0000b", "博推"}, wp.BasicTokenize("a\u0000b 博推"))
+}